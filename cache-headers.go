@@ -0,0 +1,51 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// bodyETag returns the entity tag of a body: the quoted SHA-256 hash of its
+// bytes.
+func bodyETag(body string) string {
+	hash := sha256.Sum256([]byte(body))
+	return `"` + hex.EncodeToString(hash[:]) + `"`
+}
+
+// addCacheHeaders adds an ETag header, hashed from the body served, and a
+// Last-Modified header, the modification time of the spec, to the responses
+// with a body. Both are stable from run to run. Specs not read from a file,
+// e.g. given to the conversion service, are dated at the Unix epoch. The
+// headers added by a previous call, e.g. for another target, are replaced.
+func (m *MockServerSetting) addCacheHeaders(modified time.Time) {
+	if modified.IsZero() {
+		modified = time.Unix(0, 0)
+	}
+	lastModified := modified.UTC().Format(http.TimeFormat)
+	for i, request := range m.Requests {
+		for j, response := range request.Responses {
+			if response.Body == nil {
+				continue
+			}
+			body := *response.Body
+			if m.Options.TrailingNewline && !m.Options.SingleFile {
+				body = strings.TrimRight(body, "\n") + "\n"
+			}
+
+			// The header lists may be shared by the responses of a content type
+			headers := []Header{}
+			if response.Headers != nil {
+				for _, header := range *response.Headers {
+					if !strings.EqualFold(header.Name, "ETag") && !strings.EqualFold(header.Name, "Last-Modified") {
+						headers = append(headers, header)
+					}
+				}
+			}
+			headers = append(headers, Header{Name: "ETag", Value: bodyETag(body)}, Header{Name: "Last-Modified", Value: lastModified})
+			m.Requests[i].Responses[j].Headers = &headers
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestCacheHeaders(t *testing.T) {
+	targetFolder := t.TempDir()
+	setting, _ := generateInto(t, targetFolder, mergeSpec, Options{Port: 8080, EmitCacheHeaders: true})
+
+	for _, request := range setting.Requests {
+		for _, response := range request.Responses {
+			headers := map[string]string{}
+			if response.Headers != nil {
+				for _, header := range *response.Headers {
+					headers[header.Name] = header.Value
+				}
+			}
+			if response.FilePath == nil {
+				if _, ok := headers["ETag"]; ok {
+					t.Errorf("Expected no ETag without a body for %s", request.Path)
+				}
+				continue
+			}
+
+			body, err := os.ReadFile(filepath.Join(targetFolder, *response.FilePath))
+			if err != nil {
+				t.Fatalf("Failed to read body: %v", err)
+			}
+			hash := sha256.Sum256(body)
+			if etag := `"` + hex.EncodeToString(hash[:]) + `"`; headers["ETag"] != etag {
+				t.Errorf("Expected the ETag %s hashed from the body, got %s", etag, headers["ETag"])
+			}
+			if _, err := time.Parse(http.TimeFormat, headers["Last-Modified"]); err != nil {
+				t.Errorf("Expected an HTTP date as Last-Modified, got %q", headers["Last-Modified"])
+			}
+			if headers["Content-Type"] != "application/json" {
+				t.Errorf("Expected the content type kept, got %q", headers["Content-Type"])
+			}
+		}
+	}
+}
+
+func TestCacheHeadersStable(t *testing.T) {
+	openApiFile := filepath.Join(t.TempDir(), "openapi.yaml")
+	if err := os.WriteFile(openApiFile, []byte(mergeSpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	modified := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(openApiFile, modified, modified); err != nil {
+		t.Fatal(err)
+	}
+
+	// The cache headers of each response, by route
+	runs := []string{}
+	for i := 0; i < 2; i++ {
+		target := t.TempDir()
+		if err := Generate(openApiFile, target, Options{Port: 8080, EmitCacheHeaders: true}); err != nil {
+			t.Fatalf("Failed to generate: %v", err)
+		}
+		data, err := os.ReadFile(filepath.Join(target, "data", "Merge_API", "setting.yaml"))
+		if err != nil {
+			t.Fatalf("Failed to read setting: %v", err)
+		}
+		var saved MockServerSetting
+		if err := yaml.Unmarshal(data, &saved); err != nil {
+			t.Fatalf("Failed to parse setting: %v", err)
+		}
+		headers := []string{}
+		for _, request := range saved.Requests {
+			for _, response := range request.Responses {
+				if response.Headers == nil {
+					continue
+				}
+				for _, header := range *response.Headers {
+					if header.Name == "ETag" || header.Name == "Last-Modified" {
+						headers = append(headers, fmt.Sprintf("%s %s %d %s: %s", request.Method, request.Path, response.Code, header.Name, header.Value))
+					}
+				}
+			}
+		}
+		sort.Strings(headers)
+		runs = append(runs, strings.Join(headers, "\n"))
+	}
+	if runs[0] != runs[1] {
+		t.Errorf("Expected the same cache headers from run to run, got:\n%s\nand:\n%s", runs[0], runs[1])
+	}
+	if lastModified := modified.Format(http.TimeFormat); !strings.Contains(runs[0], "Last-Modified: "+lastModified) {
+		t.Errorf("Expected the modification time of the spec %s as Last-Modified, got:\n%s", lastModified, runs[0])
+	}
+}
+
+func TestCacheHeadersMultipleTargets(t *testing.T) {
+	openApiFile := filepath.Join(t.TempDir(), "openapi.yaml")
+	if err := os.WriteFile(openApiFile, []byte(mergeSpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	first, second := t.TempDir(), t.TempDir()
+	if err := exportOpenAPIToMockServer(openApiFile, []string{first, second}, Options{Port: 8080, EmitCacheHeaders: true}); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	settings := []string{}
+	for _, target := range []string{first, second} {
+		data, err := os.ReadFile(filepath.Join(target, "data", "Merge_API", "setting.yaml"))
+		if err != nil {
+			t.Fatalf("Failed to read setting: %v", err)
+		}
+		settings = append(settings, string(data))
+	}
+	if settings[0] != settings[1] {
+		t.Errorf("Expected the same setting in each target, got:\n%s\nand:\n%s", settings[0], settings[1])
+	}
+	if count := strings.Count(settings[1], "name: ETag"); count != strings.Count(settings[1], "filePath:") {
+		t.Errorf("Expected one ETag per body, got %d in:\n%s", count, settings[1])
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import "strings"
+
+// commonPathPrefix returns the longest prefix of whole path segments shared by
+// the paths of the requests, e.g. "/api/v1". The last segment of each path and
+// templated segments are never part of the prefix, so that a single operation
+// keeps its last segment and no path parameter is dropped. Webhooks and
+// callbacks are not routed under the paths, so they are ignored.
+func commonPathPrefix(requests []Request) string {
+	var prefix []string
+	found := false
+	for _, request := range requests {
+		if request.Webhook || request.Callback != "" {
+			continue
+		}
+		segments := strings.Split(strings.Trim(request.Path, "/"), "/")
+		segments = segments[:len(segments)-1]
+		if !found {
+			prefix, found = segments, true
+		}
+		n := 0
+		for n < len(prefix) && n < len(segments) && prefix[n] == segments[n] && !strings.Contains(segments[n], "{") {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	if len(prefix) == 0 {
+		return ""
+	}
+	return "/" + strings.Join(prefix, "/")
+}
+
+// trimPathPrefix strips a path prefix from the paths of the requests and from
+// the path infos.
+func trimPathPrefix(prefix string, requests []Request, paths map[string]PathInfo) map[string]PathInfo {
+	for i, request := range requests {
+		if request.Webhook || request.Callback != "" {
+			continue
+		}
+		requests[i].Path = strings.TrimPrefix(request.Path, prefix)
+	}
+	trimmedPaths := make(map[string]PathInfo, len(paths))
+	for path, info := range paths {
+		if strings.HasPrefix(path, prefix+"/") {
+			path = strings.TrimPrefix(path, prefix)
+		}
+		trimmedPaths[path] = info
+	}
+	return trimmedPaths
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+const commonPrefixSpec = `
+openapi: "3.0.0"
+info:
+  title: Prefix API
+  version: 1.0.0
+paths:
+  /api/v1/pets:
+    summary: Pets
+    get:
+      responses:
+        "204":
+          description: No Content
+  /api/v1/pets/{id}:
+    get:
+      responses:
+        "204":
+          description: No Content
+  /api/v1/owners:
+    get:
+      responses:
+        "204":
+          description: No Content
+`
+
+func TestTrimCommonPrefix(t *testing.T) {
+	setting := ConvertOpenAPIToMockServer(loadTestSpec(t, commonPrefixSpec), Options{TrimCommonPrefix: true})
+
+	var paths []string
+	for _, request := range setting.Requests {
+		paths = append(paths, request.Path)
+	}
+	sort.Strings(paths)
+	if joined := strings.Join(paths, ","); joined != "/owners,/pets,/pets/{id}" {
+		t.Errorf("Expected the common prefix to be trimmed, got %s", joined)
+	}
+	if setting.Paths["/pets"].Summary != "Pets" {
+		t.Errorf("Expected the path infos to be trimmed, got %v", setting.Paths)
+	}
+}
+
+func TestCommonPathPrefix(t *testing.T) {
+	cases := []struct {
+		paths  []string
+		prefix string
+	}{
+		{[]string{"/api/v1/pets"}, "/api/v1"},
+		{[]string{"/pets"}, ""},
+		{[]string{"/api/v1/pets", "/api/v2/pets"}, "/api"},
+		{[]string{"/{tenant}/pets", "/{tenant}/owners"}, ""},
+		{[]string{"/api/pets", "/api"}, ""},
+	}
+	for _, c := range cases {
+		var requests []Request
+		for _, path := range c.paths {
+			requests = append(requests, Request{Path: path})
+		}
+		if prefix := commonPathPrefix(requests); prefix != c.prefix {
+			t.Errorf("Expected the common prefix of %v to be %q, got %q", c.paths, c.prefix, prefix)
+		}
+	}
+}
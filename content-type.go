@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// eventStreamContentType is the content type of server-sent events.
+const eventStreamContentType = "text/event-stream"
+
+// ndjsonContentType is the content type of newline-delimited JSON.
+const ndjsonContentType = "application/x-ndjson"
+
+// defaultExtensions maps content types to the extension of the files their bodies
+// are saved to.
+var defaultExtensions = map[string]string{
+	"application/json":         ".json",
+	"application/xml":          ".xml",
+	"text/xml":                 ".xml",
+	"text/html":                ".html",
+	"text/plain":               ".txt",
+	"text/csv":                 ".csv",
+	"text/css":                 ".css",
+	"text/javascript":          ".js",
+	"application/javascript":   ".js",
+	"application/yaml":         ".yaml",
+	"application/x-yaml":       ".yaml",
+	"text/yaml":                ".yaml",
+	"application/pdf":          ".pdf",
+	"application/octet-stream": ".bin",
+	"image/png":                ".png",
+	"image/jpeg":               ".jpg",
+	"image/gif":                ".gif",
+	"image/svg+xml":            ".svg",
+	"text/event-stream":        ".sse",
+	"application/x-ndjson":     ".ndjson",
+}
+
+// fileExtension returns the extension of the file a body of the given content
+// type is saved to. The overrides take precedence over the default mapping.
+// Unknown content types keep the historical ".json" extension.
+func fileExtension(contentType string, overrides map[string]string) string {
+	mediaType := strings.ToLower(strings.TrimSpace(contentType))
+	if parsed, _, err := mime.ParseMediaType(mediaType); err == nil {
+		mediaType = parsed
+	}
+
+	for _, extensions := range []map[string]string{overrides, defaultExtensions} {
+		if extension, ok := extensions[mediaType]; ok {
+			if extension != "" && !strings.HasPrefix(extension, ".") {
+				extension = "." + extension
+			}
+			return extension
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(mediaType, "+json"):
+		return ".json"
+	case strings.HasSuffix(mediaType, "+xml"):
+		return ".xml"
+	case strings.HasPrefix(mediaType, "text/"):
+		return ".txt"
+	}
+	return ".json"
+}
+
+// contentTypeOverride returns the content type configured for a route. The
+// overrides are keyed by "METHOD path", the method being case-insensitive.
+func contentTypeOverride(overrides map[string]string, method string, path string) (string, bool) {
+	for route, contentType := range overrides {
+		routeMethod, routePath, ok := strings.Cut(strings.TrimSpace(route), " ")
+		if ok && strings.EqualFold(routeMethod, method) && strings.TrimSpace(routePath) == path {
+			return contentType, true
+		}
+	}
+	return "", false
+}
+
+// overrideContentType replaces the Content-Type header of the responses that
+// have one.
+func overrideContentType(responses []Response, contentType string) {
+	for i, response := range responses {
+		if response.Headers == nil {
+			continue
+		}
+		headers := make([]Header, len(*response.Headers))
+		copy(headers, *response.Headers)
+		for j, header := range headers {
+			if strings.EqualFold(header.Name, "Content-Type") {
+				headers[j].Value = contentType
+			}
+		}
+		responses[i].Headers = &headers
+	}
+}
+
+// detectContentTypes replaces the wildcard or empty content types of the
+// responses with bodies, like */*, by the type of their body, see
+// bodyContentType. This sets the extension of their files.
+func detectContentTypes(responses []Response) {
+	for i, response := range responses {
+		contentType := response.ContentType()
+		if response.Body == nil || contentType != "" && !strings.Contains(contentType, "*") {
+			continue
+		}
+		overrideContentType(responses[i:i+1], bodyContentType(*response.Body))
+	}
+}
+
+// bodyContentType returns the content type of a body: application/json when it
+// parses as JSON, text/plain otherwise.
+func bodyContentType(body string) string {
+	if json.Valid([]byte(body)) {
+		return "application/json"
+	}
+	return "text/plain"
+}
+
+// bareResponseBody returns the body of a response declaring its example or its
+// schema without a media type, in the style of Swagger 2. It returns false when
+// the response has neither.
+func bareResponseBody(response *openapi3.Response, schemaExamples map[string]string, opts Options) (string, bool) {
+	if example, ok := response.Extensions["example"]; ok {
+		if body := exampleBodyString(example); body != "" {
+			return body, true
+		}
+	}
+	raw, ok := response.Extensions["schema"]
+	if !ok {
+		return "", false
+	}
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return "", false
+	}
+	schema := &openapi3.SchemaRef{}
+	if err := schema.UnmarshalJSON(data); err != nil {
+		warnf("Failed to parse the schema of a response without content: %v", err)
+		return "", false
+	}
+	if schema.Ref == "" && schema.Value == nil {
+		return "", false
+	}
+	body := schemaBodyString(schema, schemaExamples, opts)
+	return body, body != ""
+}
+
+// formatEventStreams formats the bodies of the text/event-stream responses as
+// server-sent events, see eventStreamBody.
+func formatEventStreams(responses []Response) {
+	for i, response := range responses {
+		mediaType, _, err := mime.ParseMediaType(response.ContentType())
+		if response.Body == nil || err != nil || mediaType != eventStreamContentType {
+			continue
+		}
+		body := eventStreamBody(*response.Body)
+		responses[i].Body = &body
+	}
+}
+
+// formatNDJSON formats the bodies of the application/x-ndjson responses as
+// newline-delimited JSON, see ndjsonBody.
+func formatNDJSON(responses []Response) {
+	for i, response := range responses {
+		mediaType, _, err := mime.ParseMediaType(response.ContentType())
+		if response.Body == nil || err != nil || mediaType != ndjsonContentType {
+			continue
+		}
+		body := ndjsonBody(*response.Body)
+		responses[i].Body = &body
+	}
+}
+
+// ndjsonBody formats a body as newline-delimited JSON. A JSON array gives one
+// line per element, other JSON values a single line, compacted. A body already
+// made of lines is kept.
+func ndjsonBody(body string) string {
+	trimmed := strings.TrimSpace(body)
+	if !json.Valid([]byte(trimmed)) {
+		return trimmed + "\n"
+	}
+	values := []json.RawMessage{json.RawMessage(trimmed)}
+	var items []json.RawMessage
+	if json.Unmarshal([]byte(trimmed), &items) == nil {
+		values = items
+	}
+
+	var builder strings.Builder
+	for _, value := range values {
+		var compact bytes.Buffer
+		json.Compact(&compact, value)
+		builder.WriteString(compact.String() + "\n")
+	}
+	return builder.String()
+}
+
+// eventStreamBody formats a body as server-sent events, each as "data: ..."
+// lines ended by a blank line. A JSON array gives one event per element, other
+// JSON values a single event, compacted. A body already made of events is kept.
+func eventStreamBody(body string) string {
+	trimmed := strings.TrimSpace(body)
+	if strings.HasPrefix(trimmed, "data:") || strings.HasPrefix(trimmed, "event:") || strings.HasPrefix(trimmed, "id:") {
+		return strings.TrimRight(trimmed, "\n") + "\n\n"
+	}
+
+	events := []string{trimmed}
+	var items []json.RawMessage
+	if json.Unmarshal([]byte(trimmed), &items) == nil {
+		events = events[:0]
+		for _, item := range items {
+			events = append(events, string(item))
+		}
+	}
+
+	var builder strings.Builder
+	for _, event := range events {
+		var compact bytes.Buffer
+		if json.Compact(&compact, []byte(event)) == nil {
+			event = compact.String()
+		}
+		for _, line := range strings.Split(event, "\n") {
+			builder.WriteString("data: " + line + "\n")
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String()
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFileExtension(t *testing.T) {
+	overrides := map[string]string{"text/html": "htm"}
+	for contentType, expected := range map[string]string{
+		"application/json":                ".json",
+		"application/json; charset=utf-8": ".json",
+		"application/problem+json":        ".json",
+		"application/atom+xml":            ".xml",
+		"text/html":                       ".htm",
+		"text/plain":                      ".txt",
+		"text/markdown":                   ".txt",
+		"text/csv":                        ".csv",
+		"image/png":                       ".png",
+		"application/vnd.unknown":         ".json",
+	} {
+		if extension := fileExtension(contentType, overrides); extension != expected {
+			t.Errorf("expect extension %q for %s, got %q", expected, contentType, extension)
+		}
+	}
+}
+
+func TestContentTypeOverride(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Legacy API
+  version: 1.0.0
+paths:
+  /legacy:
+    get:
+      operationId: getLegacy
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              example: <legacy/>
+  /modern:
+    get:
+      operationId: getModern
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              example: {"modern": true}
+`)
+	requests := getRequests(spec, getSchemaExamples(spec, Options{}), Options{
+		ContentTypes: map[string]string{"get /legacy": "text/xml"},
+	})
+
+	contentTypes := map[string]string{}
+	for _, request := range requests {
+		contentTypes[request.Name] = request.Responses[0].ContentType()
+	}
+	if contentTypes["getLegacy"] != "text/xml" {
+		t.Errorf("expect overridden content type for GET /legacy, got %q", contentTypes["getLegacy"])
+	}
+	if contentTypes["getModern"] != "application/json" {
+		t.Errorf("expect spec content type for GET /modern, got %q", contentTypes["getModern"])
+	}
+}
+
+func TestDetectContentTypes(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Any API
+  version: 1.0.0
+paths:
+  /json:
+    get:
+      operationId: getJSON
+      responses:
+        '200':
+          description: OK
+          content:
+            '*/*':
+              example: '{"name": "Tom"}'
+  /text:
+    get:
+      operationId: getText
+      responses:
+        '200':
+          description: OK
+          content:
+            '*/*':
+              example: Hello, Tom
+  /xml:
+    get:
+      operationId: getXML
+      responses:
+        '200':
+          description: OK
+          content:
+            application/xml:
+              example: '{"name": "Tom"}'
+`)
+	requests := getRequests(spec, getSchemaExamples(spec, Options{}), Options{})
+
+	contentTypes := map[string]string{}
+	for _, request := range requests {
+		contentTypes[request.Name] = request.Responses[0].ContentType()
+	}
+	for name, expected := range map[string]string{
+		"getJSON": "application/json",
+		"getText": "text/plain",
+		"getXML":  "application/xml",
+	} {
+		if contentTypes[name] != expected {
+			t.Errorf("expect content type %s for %s, got %s", expected, name, contentTypes[name])
+		}
+	}
+	if extension := fileExtension(contentTypes["getJSON"], nil); extension != ".json" {
+		t.Errorf("expect .json extension for the JSON string example, got %s", extension)
+	}
+}
+
+func TestFormatEventStreams(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Event API
+  version: 1.0.0
+paths:
+  /ticks:
+    get:
+      operationId: getTicks
+      responses:
+        '200':
+          description: OK
+          content:
+            text/event-stream:
+              example:
+                - {"tick": 1}
+                - {"tick": 2}
+  /greeting:
+    get:
+      operationId: getGreeting
+      responses:
+        '200':
+          description: OK
+          content:
+            text/event-stream:
+              example: Hello, Tom
+`)
+	requests := getRequests(spec, getSchemaExamples(spec, Options{}), Options{})
+
+	bodies := map[string]string{}
+	for _, request := range requests {
+		bodies[request.Name] = *request.Responses[0].Body
+	}
+	if bodies["getTicks"] != "data: {\"tick\":1}\n\ndata: {\"tick\":2}\n\n" {
+		t.Errorf("expect one event per array element, got %q", bodies["getTicks"])
+	}
+	if bodies["getGreeting"] != "data: Hello, Tom\n\n" {
+		t.Errorf("expect a single event, got %q", bodies["getGreeting"])
+	}
+	if extension := fileExtension("text/event-stream", nil); extension != ".sse" {
+		t.Errorf("expect .sse extension, got %s", extension)
+	}
+}
+
+func TestInferredContentType(t *testing.T) {
+	spec := `
+openapi: "3.0.0"
+info:
+  title: Inferred API
+  version: 1.0.0
+paths:
+  /example:
+    get:
+      responses:
+        "200":
+          description: OK
+          example:
+            name: Tom
+  /schema:
+    get:
+      responses:
+        "200":
+          description: OK
+          schema:
+            $ref: "#/components/schemas/Pet"
+  /empty:
+    get:
+      responses:
+        "200":
+          description: OK
+          content:
+            "":
+              example:
+                name: Jerry
+  /none:
+    get:
+      responses:
+        "204":
+          description: No Content
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name:
+          type: string
+          example: Spike
+`
+	setting := ConvertOpenAPIToMockServer(loadTestSpec(t, spec), Options{})
+	expected := map[string]string{"/example": "Tom", "/schema": "Spike", "/empty": "Jerry"}
+	for _, request := range setting.Requests {
+		response := request.Responses[0]
+		name, ok := expected[request.Path]
+		if !ok {
+			if response.Body != nil || response.ContentType() != "" {
+				t.Errorf("Expected no body for %s, got %q", request.Path, response.ContentType())
+			}
+			continue
+		}
+		if response.ContentType() != "application/json" {
+			t.Errorf("Expected the application/json content type for %s, got %q", request.Path, response.ContentType())
+		}
+		if response.Body == nil || !strings.Contains(*response.Body, name) {
+			t.Errorf("Expected a body with %s for %s, got %v", name, request.Path, response.Body)
+		}
+	}
+}
+
+func TestFormatNDJSON(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Stream API
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      responses:
+        '200':
+          description: OK
+          content:
+            application/x-ndjson:
+              example:
+                - {"name": "Tom", "tags": ["cat"]}
+                - {"name": "Jerry"}
+`)
+	response := getRequests(spec, getSchemaExamples(spec, Options{}), Options{})[0].Responses[0]
+	expected := "{\"name\":\"Tom\",\"tags\":[\"cat\"]}\n{\"name\":\"Jerry\"}\n"
+	if response.Body == nil || *response.Body != expected {
+		t.Errorf("Expected one JSON object per line %q, got %v", expected, response.Body)
+	}
+	if extension := fileExtension(response.ContentType(), nil); extension != ".ndjson" {
+		t.Errorf("Expected the .ndjson extension, got %s", extension)
+	}
+	if body := ndjsonBody("{\"a\":1}\n{\"a\":2}"); body != "{\"a\":1}\n{\"a\":2}\n" {
+		t.Errorf("Expected a body already in lines kept, got %q", body)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// csvExtension is the extension of a response giving a CSV file, relative to
+// the OpenAPI file, served as its body instead of the generated ones.
+const csvExtension = "x-mock-csv"
+
+// csvContentType is the content type of the responses from a CSV file.
+const csvContentType = "text/csv"
+
+// csvFilePath returns the path of the CSV file of a response, resolved against
+// the folder of the OpenAPI file. It returns false without the extension or
+// without a folder, and an error for a path outside of the folder.
+func csvFilePath(response *openapi3.Response, specDir string) (string, bool, error) {
+	if response == nil || specDir == "" {
+		return "", false, nil
+	}
+	raw, ok := response.Extensions[csvExtension]
+	if !ok {
+		return "", false, nil
+	}
+	path, ok := raw.(string)
+	if !ok || path == "" {
+		return "", true, fmt.Errorf("invalid %s %v, expected a file path", csvExtension, raw)
+	}
+	if filepath.IsAbs(path) {
+		return "", true, fmt.Errorf("invalid %s %q, expected a path relative to the OpenAPI file", csvExtension, path)
+	}
+	resolved := filepath.Join(specDir, path)
+	rel, err := filepath.Rel(specDir, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", true, fmt.Errorf("invalid %s %q, expected a path inside the folder of the OpenAPI file", csvExtension, path)
+	}
+	return resolved, true, nil
+}
+
+// csvResponse returns the response of a code with the content of its CSV file
+// as body. It returns false when the response has no CSV file or when the file
+// cannot be read.
+func csvResponse(key string, code int, description string, response *openapi3.Response, opts Options) (Response, bool) {
+	path, ok, err := csvFilePath(response, opts.specDir)
+	if err == nil && ok {
+		var data []byte
+		if data, err = os.ReadFile(path); err == nil {
+			body := string(data)
+			return Response{
+				Name:        opts.responseName(description),
+				Description: description,
+				Code:        code,
+				Query:       opts.selectorQuery(SelectorKey, key, SelectorContentType, csvContentType),
+				Headers:     &[]Header{{Name: "Content-Type", Value: csvContentType}},
+				Body:        &body,
+			}, true
+		}
+	}
+	if err != nil {
+		warnf("%v, CSV file of response %s skipped", err, key)
+	}
+	return Response{}, false
+}
+
+// missingCSVFiles describes each response of the spec whose CSV file does not
+// exist.
+func missingCSVFiles(openAPISpec openapi3.T, specDir string) []string {
+	missing := []string{}
+	for path, pathItem := range openAPISpec.Paths.Map() {
+		for method, operation := range pathItem.Operations() {
+			if operation.Responses == nil {
+				continue
+			}
+			for code, response := range operation.Responses.Map() {
+				if response.Value == nil {
+					continue
+				}
+				location := fmt.Sprintf("%s %s %s", method, path, code)
+				file, ok, err := csvFilePath(response.Value, specDir)
+				if err != nil {
+					missing = append(missing, fmt.Sprintf("%s: %v", location, err))
+				} else if _, err := os.Stat(file); ok && err != nil {
+					missing = append(missing, fmt.Sprintf("%s: %q", location, file))
+				}
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
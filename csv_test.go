@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const csvSpec = `
+openapi: "3.0.0"
+info:
+  title: CSV API
+  version: 1.0.0
+paths:
+  /users:
+    get:
+      responses:
+        "200":
+          description: OK
+          x-mock-csv: ./data/users.csv
+          content:
+            application/json:
+              example: []
+`
+
+func TestCSVResponse(t *testing.T) {
+	specDir := t.TempDir()
+	csv := "id,name\n1,Tom\n2,Jerry\n"
+	if err := os.MkdirAll(filepath.Join(specDir, "data"), 0755); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(specDir, "data", "users.csv"), []byte(csv), 0644); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+
+	spec := loadTestSpec(t, csvSpec)
+	if missing := missingCSVFiles(spec, specDir); len(missing) != 0 {
+		t.Errorf("Expected the CSV file to be found, got %v", missing)
+	}
+
+	setting := ConvertOpenAPIToMockServer(spec, Options{specDir: specDir})
+	responses := setting.Requests[0].Responses
+	if len(responses) != 1 {
+		t.Fatalf("Expected the CSV file to replace the example, got %d responses", len(responses))
+	}
+	if contentType := responses[0].ContentType(); contentType != "text/csv" {
+		t.Errorf("Expected the text/csv content type, got %q", contentType)
+	}
+	if responses[0].Body == nil || *responses[0].Body != csv {
+		t.Errorf("Expected the CSV file as body, got %v", responses[0].Body)
+	}
+}
+
+func TestMissingCSVFiles(t *testing.T) {
+	missing := missingCSVFiles(loadTestSpec(t, csvSpec), t.TempDir())
+	if len(missing) != 1 || !strings.Contains(missing[0], "users.csv") {
+		t.Errorf("Expected the missing CSV file to be reported, got %v", missing)
+	}
+}
+
+func TestCSVFilePathOutsideSpecDir(t *testing.T) {
+	specDir := t.TempDir()
+	for _, path := range []string{"/etc/hostname", "../secret.csv", "data/../../secret.csv"} {
+		response := &openapi3.Response{Extensions: map[string]any{csvExtension: path}}
+		if _, ok, err := csvFilePath(response, specDir); !ok || err == nil {
+			t.Errorf("Expected %q to be rejected, got %v, %v", path, ok, err)
+		}
+		if _, ok := csvResponse("200", 200, "OK", response, Options{specDir: specDir}); ok {
+			t.Errorf("Expected no response from %q", path)
+		}
+	}
+
+	response := &openapi3.Response{Extensions: map[string]any{csvExtension: "data/users.csv"}}
+	if path, _, err := csvFilePath(response, specDir); err != nil || path != filepath.Join(specDir, "data", "users.csv") {
+		t.Errorf("Expected the path inside the folder, got %q, %v", path, err)
+	}
+}
+
+func TestCSVResponseWithoutSpecDir(t *testing.T) {
+	spec := strings.Replace(csvSpec, "./data/users.csv", "/etc/hostname", 1)
+	setting := ConvertOpenAPIToMockServer(loadTestSpec(t, spec), Options{})
+	responses := setting.Requests[0].Responses
+	if len(responses) != 1 || responses[0].ContentType() == csvContentType {
+		t.Fatalf("Expected the extension to be ignored without a spec folder, got %+v", responses)
+	}
+	if responses[0].Body != nil && strings.TrimSpace(*responses[0].Body) != "[]" {
+		t.Errorf("Expected the example as body, got %q", *responses[0].Body)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// delayExtension is the extension of a response giving the latency of the mock
+// server in milliseconds, either fixed, e.g. 200, or a range, e.g. "100-300".
+const delayExtension = "x-mock-delay-ms"
+
+// responseDelay returns the minimum and maximum latency of a response from its
+// delay extension. Both are 0 without the extension.
+func responseDelay(response *openapi3.Response) (int, int, error) {
+	raw, ok := response.Extensions[delayExtension]
+	if !ok {
+		return 0, 0, nil
+	}
+	return parseDelay(raw)
+}
+
+// parseDelay parses a delay in milliseconds, either a number or a "min-max"
+// range.
+func parseDelay(raw interface{}) (int, int, error) {
+	var value string
+	switch raw := raw.(type) {
+	case float64:
+		value = strconv.FormatFloat(raw, 'f', -1, 64)
+	case string:
+		value = raw
+	default:
+		return 0, 0, fmt.Errorf("invalid %s %v, expected milliseconds or a range like 100-300", delayExtension, raw)
+	}
+
+	minValue, maxValue, isRange := strings.Cut(value, "-")
+	if !isRange {
+		maxValue = minValue
+	}
+	minDelay, minErr := strconv.Atoi(strings.TrimSpace(minValue))
+	maxDelay, maxErr := strconv.Atoi(strings.TrimSpace(maxValue))
+	if minErr != nil || maxErr != nil || minDelay < 0 {
+		return 0, 0, fmt.Errorf("invalid %s %q, expected milliseconds or a range like 100-300", delayExtension, value)
+	}
+	if minDelay > maxDelay {
+		return 0, 0, fmt.Errorf("invalid %s %q, the minimum is greater than the maximum", delayExtension, value)
+	}
+	return minDelay, maxDelay, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestExtractResponseDelay(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Slow API
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        '200':
+          description: OK
+          x-mock-delay-ms: "100-300"
+          content:
+            application/json:
+              example: []
+        '404':
+          description: Not Found
+          x-mock-delay-ms: 50
+        '500':
+          description: Error
+          x-mock-delay-ms: "300-100"
+`)
+	requests := getRequests(spec, getSchemaExamples(spec, Options{}), Options{})
+
+	delays := []string{}
+	for _, response := range requests[0].Responses {
+		delays = append(delays, fmt.Sprintf("%d:%d-%d", response.Code, response.DelayMinMs, response.DelayMaxMs))
+	}
+	if fmt.Sprint(delays) != "[200:100-300 404:50-50 500:0-0]" {
+		t.Errorf("unexpected delays %v", delays)
+	}
+}
+
+func TestParseDelay(t *testing.T) {
+	for raw, expected := range map[interface{}]string{
+		float64(200): "200-200",
+		"200":        "200-200",
+		" 100 - 300": "100-300",
+	} {
+		minDelay, maxDelay, err := parseDelay(raw)
+		if err != nil || fmt.Sprintf("%d-%d", minDelay, maxDelay) != expected {
+			t.Errorf("expect %s for %v, got %d-%d (%v)", expected, raw, minDelay, maxDelay, err)
+		}
+	}
+	for _, raw := range []interface{}{"300-100", "fast", "-5", true} {
+		if _, _, err := parseDelay(raw); err == nil {
+			t.Errorf("expect error for %v", raw)
+		}
+	}
+}
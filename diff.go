@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// routeSnapshot is the body of each response of each route of a generation,
+// keyed by "METHOD path" and then by "code name content-type example". A nil
+// body is a response without a body.
+type routeSnapshot map[string]map[string]*string
+
+// snapshotKeys returns the key of a request and of a response in a snapshot.
+// The named examples of a response are told apart by their selector.
+func snapshotKeys(request Request, response Response, opts Options) (string, string) {
+	route := fmt.Sprintf("%s %s", strings.ToUpper(request.Method), request.Path)
+	if request.Callback != "" {
+		route += " " + request.Callback
+	}
+	example := ""
+	if query, err := url.ParseQuery(strings.TrimPrefix(response.Query, "?")); err == nil {
+		example = query.Get(opts.selectorParam(SelectorName))
+	}
+	return route, strings.TrimSpace(fmt.Sprintf("%d %s %s %s", response.Code, cleanFolderName(response.Name), response.ContentType(), example))
+}
+
+// snapshot returns the routes generated for the mock server, before they are
+// saved.
+func (m *MockServerSetting) snapshot() routeSnapshot {
+	routes := routeSnapshot{}
+	for _, request := range m.Requests {
+		route, _ := snapshotKeys(request, Response{}, m.Options)
+		if routes[route] == nil {
+			routes[route] = map[string]*string{}
+		}
+		for _, response := range request.Responses {
+			_, key := snapshotKeys(request, response, m.Options)
+			if _, ok := routes[route][key]; !ok {
+				routes[route][key] = response.Body
+			}
+		}
+	}
+	return routes
+}
+
+// loadPreviousSnapshot reads the routes of the setting file previously saved
+// into the folder of the mock server, with their bodies. It returns an empty
+// snapshot when there is no such file.
+func (m *MockServerSetting) loadPreviousSnapshot() (routeSnapshot, error) {
+	routes := routeSnapshot{}
+	data, err := os.ReadFile(m.settingFilePath())
+	if os.IsNotExist(err) {
+		return routes, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read previous mock server setting: %w", err)
+	}
+
+	// JSON is valid YAML, so both setting formats are read the same way
+	var previous MockServerSetting
+	if err := yaml.Unmarshal(data, &previous); err != nil {
+		return nil, fmt.Errorf("failed to parse previous mock server setting: %w", err)
+	}
+	bodyFiles := map[string]map[string]json.RawMessage{}
+	for _, request := range previous.Requests {
+		route, _ := snapshotKeys(request, Response{}, m.Options)
+		if routes[route] == nil {
+			routes[route] = map[string]*string{}
+		}
+		for _, response := range request.Responses {
+			_, key := snapshotKeys(request, response, m.Options)
+			if _, ok := routes[route][key]; ok {
+				continue
+			}
+			var body *string
+			if response.FilePath != nil {
+				body = m.readPreviousBody(*response.FilePath, bodyFiles)
+			}
+			routes[route][key] = body
+		}
+	}
+	return routes, nil
+}
+
+// readPreviousBody reads a body recorded in the previous setting, from its own
+// file or from a JSON pointer into the bodies file. It returns nil when the
+// body cannot be read.
+func (m *MockServerSetting) readPreviousBody(recordedPath string, bodyFiles map[string]map[string]json.RawMessage) *string {
+	recordedPath, pointer, _ := strings.Cut(recordedPath, "#/")
+
+	// The paths are relative to the target folder or to the setting file,
+	// depending on the path style
+	path := recordedPath
+	if !filepath.IsAbs(path) {
+		base := m.Folder
+		if strings.HasPrefix(path, m.targetPathPrefix()) {
+			base = m.TargetFolder
+		}
+		path = filepath.Join(base, path)
+	}
+
+	if pointer == "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+		body := string(data)
+		return &body
+	}
+
+	bodies, ok := bodyFiles[path]
+	if !ok {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal(data, &bodies)
+		}
+		if err != nil {
+			warnf("Failed to read previous response bodies %s: %v", path, err)
+		}
+		bodyFiles[path] = bodies
+	}
+	raw, ok := bodies[strings.NewReplacer("~1", "/", "~0", "~").Replace(pointer)]
+	if !ok {
+		return nil
+	}
+	body := string(raw)
+	var text string
+	if json.Unmarshal(raw, &text) == nil {
+		body = text
+	}
+	return &body
+}
+
+// diffSnapshots returns the changes from the previous routes to the new ones:
+// the added and removed routes, the changed response codes and the changed
+// bodies.
+func diffSnapshots(previous, routes routeSnapshot) []string {
+	changes := []string{}
+	for _, route := range sortedKeys(routes) {
+		if _, ok := previous[route]; !ok {
+			changes = append(changes, fmt.Sprintf("+ %s", route))
+		}
+	}
+	for _, route := range sortedKeys(previous) {
+		if _, ok := routes[route]; !ok {
+			changes = append(changes, fmt.Sprintf("- %s", route))
+		}
+	}
+	for _, route := range sortedKeys(routes) {
+		old, ok := previous[route]
+		if !ok {
+			continue
+		}
+		if before, after := responseCodes(old), responseCodes(routes[route]); before != after {
+			changes = append(changes, fmt.Sprintf("~ %s: codes %s -> %s", route, before, after))
+		}
+		for _, key := range sortedKeys(routes[route]) {
+			oldBody, ok := old[key]
+			if ok && !sameBody(oldBody, routes[route][key]) {
+				changes = append(changes, fmt.Sprintf("~ %s %s: body changed", route, key))
+			}
+		}
+	}
+	return changes
+}
+
+// responseCodes returns the sorted distinct codes of the responses of a route,
+// separated by commas.
+func responseCodes(responses map[string]*string) string {
+	seen := map[int]bool{}
+	codes := []int{}
+	for key := range responses {
+		code, _ := strconv.Atoi(strings.Fields(key)[0])
+		if !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	sort.Ints(codes)
+	list := make([]string, len(codes))
+	for i, code := range codes {
+		list[i] = strconv.Itoa(code)
+	}
+	return strings.Join(list, ",")
+}
+
+// sameBody reports whether two bodies are the same, ignoring the formatting of
+// JSON bodies and the surrounding white space.
+func sameBody(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return normalizeBody(*a) == normalizeBody(*b)
+}
+
+// normalizeBody returns a body without the formatting of JSON or the
+// surrounding white space.
+func normalizeBody(body string) string {
+	var compact bytes.Buffer
+	if json.Compact(&compact, []byte(body)) == nil {
+		return compact.String()
+	}
+	return strings.TrimSpace(body)
+}
+
+// sortedKeys returns the sorted keys of a map.
+func sortedKeys[V any](values map[string]V) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeDiff writes the changes from the previous generation of the mock server
+// to the diff file, or to the standard output for "-".
+func (m *MockServerSetting) writeDiff(previous routeSnapshot) error {
+	changes := diffSnapshots(previous, m.snapshot())
+	var out io.Writer = os.Stdout
+	if m.Options.Diff != "-" {
+		file, err := os.OpenFile(m.Options.Diff, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, m.Options.fileMode())
+		if err != nil {
+			return fmt.Errorf("failed to create diff file: %w", err)
+		}
+		defer file.Close()
+		out = file
+	}
+	if len(changes) == 0 {
+		infof("No changes since the previous generation of %s", m.Folder)
+	}
+	for _, change := range changes {
+		if _, err := fmt.Fprintln(out, change); err != nil {
+			return fmt.Errorf("failed to write diff: %w", err)
+		}
+	}
+	return nil
+}
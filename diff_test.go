@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const diffChangedSpec = `
+openapi: "3.0.0"
+info:
+  title: Merge API
+  version: 1.0.0
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              example:
+                name: Jerry
+        "404":
+          description: Not Found
+  /toys:
+    get:
+      operationId: listToys
+      responses:
+        "204":
+          description: No Content
+`
+
+func TestDiff(t *testing.T) {
+	for _, opts := range []Options{{Port: 8080}, {Port: 8080, SingleFile: true}, {Port: 8080, PathStyle: PathStyleRelative}} {
+		targetFolder := t.TempDir()
+		diffFile := filepath.Join(t.TempDir(), "diff.txt")
+		opts.Diff = diffFile
+		generateInto(t, targetFolder, mergeSpec, opts)
+		generateInto(t, targetFolder, diffChangedSpec, opts)
+
+		data, err := os.ReadFile(diffFile)
+		if err != nil {
+			t.Fatalf("Failed to read diff: %v", err)
+		}
+		expected := strings.Join([]string{
+			"+ GET /toys",
+			"- GET /owners",
+			"~ GET /pets/{id}: codes 200 -> 200,404",
+			"~ GET /pets/{id} 200 OK application/json: body changed",
+		}, "\n") + "\n"
+		if string(data) != expected {
+			t.Errorf("Expected the diff with %+v:\n%s\ngot:\n%s", opts, expected, data)
+		}
+	}
+}
+
+func TestDiffUnchanged(t *testing.T) {
+	targetFolder := t.TempDir()
+	diffFile := filepath.Join(t.TempDir(), "diff.txt")
+	opts := Options{Port: 8080, Diff: diffFile, TrailingNewline: true}
+	generateInto(t, targetFolder, mergeSpec, opts)
+	generateInto(t, targetFolder, mergeSpec, opts)
+
+	data, err := os.ReadFile(diffFile)
+	if err != nil {
+		t.Fatalf("Failed to read diff: %v", err)
+	}
+	if len(data) != 0 {
+		t.Errorf("Expected no changes, got:\n%s", data)
+	}
+}
+
+func TestDiffUnchangedNamedExamples(t *testing.T) {
+	spec := `
+openapi: "3.0.0"
+info:
+  title: Diff API
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              examples:
+                cats:
+                  value: [{"name": "Tom"}]
+                dogs:
+                  value: [{"name": "Rex"}]
+`
+	targetFolder := t.TempDir()
+	diffFile := filepath.Join(t.TempDir(), "diff.txt")
+	opts := Options{Port: 8080, Diff: diffFile}
+	for i := 0; i < 5; i++ {
+		generateInto(t, targetFolder, spec, opts)
+		data, err := os.ReadFile(diffFile)
+		if err != nil {
+			t.Fatalf("Failed to read diff: %v", err)
+		}
+		if i > 0 && len(data) != 0 {
+			t.Fatalf("Expected no changes, got:\n%s", data)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	utf8BOM    = []byte{0xEF, 0xBB, 0xBF}
+	utf16LEBOM = []byte{0xFF, 0xFE}
+	utf16BEBOM = []byte{0xFE, 0xFF}
+)
+
+// decodeSpecData returns an OpenAPI document as UTF-8 without byte order mark.
+// Documents starting with a UTF-16 byte order mark are transcoded.
+func decodeSpecData(data []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, utf8BOM):
+		return data[len(utf8BOM):], nil
+	case bytes.HasPrefix(data, utf16LEBOM):
+		return decodeUTF16(data[len(utf16LEBOM):], binary.LittleEndian)
+	case bytes.HasPrefix(data, utf16BEBOM):
+		return decodeUTF16(data[len(utf16BEBOM):], binary.BigEndian)
+	}
+	return data, nil
+}
+
+// decodeUTF16 transcodes UTF-16 text to UTF-8.
+func decodeUTF16(data []byte, order binary.ByteOrder) ([]byte, error) {
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("invalid UTF-16 document: odd number of bytes")
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	decoded := make([]byte, 0, len(data))
+	for _, r := range utf16.Decode(units) {
+		decoded = utf8.AppendRune(decoded, r)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/binary"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestLoadOpenApiDataWithBOM(t *testing.T) {
+	spec := "openapi: \"3.0.0\"\ninfo:\n  title: Café API\n  version: 1.0.0\npaths: {}\n"
+
+	utf16Encoded := func(order binary.AppendByteOrder, bom []byte) []byte {
+		data := append([]byte{}, bom...)
+		for _, unit := range utf16.Encode([]rune(spec)) {
+			data = order.AppendUint16(data, unit)
+		}
+		return data
+	}
+	for name, data := range map[string][]byte{
+		"UTF-8 BOM":     append(append([]byte{}, utf8BOM...), spec...),
+		"UTF-16 LE BOM": utf16Encoded(binary.LittleEndian, utf16LEBOM),
+		"UTF-16 BE BOM": utf16Encoded(binary.BigEndian, utf16BEBOM),
+	} {
+		doc, err := loadOpenApiData(data)
+		if err != nil {
+			t.Errorf("expect spec with %s to load, got %v", name, err)
+			continue
+		}
+		if doc.Info.Title != "Café API" {
+			t.Errorf("expect title decoded from spec with %s, got %q", name, doc.Info.Title)
+		}
+	}
+
+	if _, err := decodeSpecData([]byte{0xFF, 0xFE, 'a'}); err == nil {
+		t.Errorf("expect error for truncated UTF-16")
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// Environment variables providing defaults for the command line, for
+// containerized runs. Flags and arguments take precedence over them.
+const (
+	envHost    = "MOCK_HOST"    // default of --host
+	envPort    = "MOCK_PORT"    // default of --port
+	envTarget  = "MOCK_TARGET"  // default of the <target-folder> argument
+	envOpenApi = "MOCK_OPENAPI" // default of the <openapi-file> argument
+)
+
+// envPortValue returns the port set by the MOCK_PORT environment variable, 0 if it is
+// not set.
+func envPortValue() (int, error) {
+	value := os.Getenv(envPort)
+	if value == "" {
+		return 0, nil
+	}
+	port, err := parsePort(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s: %w", envPort, err)
+	}
+	return port, nil
+}
+
+// commandArgs returns the OpenAPI file and the target folders from the command
+// line arguments and the --openapi and --target flags. When --openapi is given,
+// the only argument is the target folder, which is optional when --target is
+// given. A missing OpenAPI file or target folder is read from the MOCK_OPENAPI
+// or MOCK_TARGET environment variable.
+func commandArgs(args []string, openApi string, targets []string) (openApiFile string, targetFolders []string, err error) {
+	if openApi != "" {
+		args = append([]string{openApi}, args...)
+	}
+	if len(args) > 2 {
+		return "", nil, fmt.Errorf("too many arguments")
+	}
+	openApiFile = os.Getenv(envOpenApi)
+	if len(args) > 0 {
+		openApiFile = args[0]
+	}
+	if len(args) > 1 {
+		targetFolders = append(targetFolders, args[1])
+	}
+	targetFolders = append(targetFolders, targets...)
+	if len(targetFolders) == 0 && os.Getenv(envTarget) != "" {
+		targetFolders = append(targetFolders, os.Getenv(envTarget))
+	}
+	if openApiFile == "" || len(targetFolders) == 0 {
+		return "", nil, fmt.Errorf("missing arguments")
+	}
+	return openApiFile, targetFolders, nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEnvironmentDefaults(t *testing.T) {
+	t.Setenv(envHost, "127.0.0.1")
+	t.Setenv(envPort, "8080")
+	t.Setenv(envOpenApi, "/specs/petstore.yaml")
+	t.Setenv(envTarget, "/mocks")
+
+	openApiFile, targetFolders, err := commandArgs(nil, "", nil)
+	if err != nil || openApiFile != "/specs/petstore.yaml" || strings.Join(targetFolders, ",") != "/mocks" {
+		t.Errorf("expect arguments from the environment, got %q, %q (%v)", openApiFile, targetFolders, err)
+	}
+	openApiFile, targetFolders, err = commandArgs([]string{"spec.yaml"}, "", nil)
+	if err != nil || openApiFile != "spec.yaml" || strings.Join(targetFolders, ",") != "/mocks" {
+		t.Errorf("expect the argument to take precedence, got %q, %q (%v)", openApiFile, targetFolders, err)
+	}
+	openApiFile, targetFolders, err = commandArgs([]string{"spec.yaml", "out"}, "", []string{"artifacts"})
+	if err != nil || strings.Join(targetFolders, ",") != "out,artifacts" {
+		t.Errorf("expect the target argument and flags, got %q (%v)", targetFolders, err)
+	}
+	openApiFile, targetFolders, err = commandArgs([]string{"out"}, "git::https://github.com/org/repo//spec.yaml", nil)
+	if err != nil || openApiFile != "git::https://github.com/org/repo//spec.yaml" || strings.Join(targetFolders, ",") != "out" {
+		t.Errorf("expect the OpenAPI file from --openapi, got %q, %q (%v)", openApiFile, targetFolders, err)
+	}
+	if _, _, err := commandArgs([]string{"spec.yaml", "out"}, "other.yaml", nil); err == nil {
+		t.Errorf("expect error for an OpenAPI file given twice")
+	}
+
+	port, err := envPortValue()
+	if err != nil || port != 8080 {
+		t.Fatalf("expect port 8080 from the environment, got %d (%v)", port, err)
+	}
+	setting := ConvertOpenAPIToMockServer(loadTestSpec(t, petSpec), Options{Host: "127.0.0.1", Port: port})
+	if setting.Host != "127.0.0.1" || setting.Port != 8080 {
+		t.Errorf("expect host and port to be applied, got %s:%d", setting.Host, setting.Port)
+	}
+
+	t.Setenv(envPort, "http")
+	if _, err := envPortValue(); err == nil {
+		t.Errorf("expect error for an invalid port")
+	}
+}
+
+func TestCommandArgsMissing(t *testing.T) {
+	t.Setenv(envOpenApi, "")
+	t.Setenv(envTarget, "")
+	if _, _, err := commandArgs([]string{"spec.yaml"}, "", nil); err == nil {
+		t.Errorf("expect error when the target folder is missing")
+	}
+}
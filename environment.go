@@ -0,0 +1,44 @@
+package main
+
+import "github.com/getkin/kin-openapi/openapi3"
+
+// environmentExtension is the extension of an example, or of a media type for
+// its singular example, mapping environments to their example value, e.g.
+//
+//	x-mock-env:
+//	  staging: {"url": "https://staging.example.com"}
+const environmentExtension = "x-mock-env"
+
+// environmentValue returns the example value of an environment from the
+// environment extension, when it has one.
+func environmentValue(extensions map[string]interface{}, environment string) (interface{}, bool) {
+	if environment == "" {
+		return nil, false
+	}
+	values, ok := extensions[environmentExtension].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+	value, ok := values[environment]
+	return value, ok
+}
+
+// mediaTypeExample returns the singular example of a media type, for the
+// environment of the options when the media type has one.
+func mediaTypeExample(content *openapi3.MediaType, opts Options) interface{} {
+	if value, ok := environmentValue(content.Extensions, opts.Environment); ok {
+		return value
+	}
+	return content.Example
+}
+
+// namedExampleBody returns the body of a named example, for the environment of
+// the options when the example has one.
+func namedExampleBody(example *openapi3.ExampleRef, opts Options) string {
+	if example != nil && example.Value != nil {
+		if value, ok := environmentValue(example.Value.Extensions, opts.Environment); ok {
+			return exampleBodyString(value)
+		}
+	}
+	return getBodyString(example)
+}
@@ -0,0 +1,55 @@
+package main
+
+import "testing"
+
+func TestExtractResponseEnvironment(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Env API
+  version: 1.0.0
+paths:
+  /config:
+    get:
+      operationId: getConfig
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              examples:
+                default:
+                  value: {"source": "default"}
+                  x-mock-env:
+                    staging: {"source": "staging"}
+                    prod: {"source": "prod"}
+  /status:
+    get:
+      operationId: getStatus
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              example: {"source": "default"}
+              x-mock-env:
+                dev: {"source": "dev"}
+`)
+	sources := func(opts Options) map[string][]string {
+		return responseSources(t, getRequests(spec, getSchemaExamples(spec, opts), opts))
+	}
+
+	staging := sources(Options{Environment: "staging"})
+	if staging["getConfig"][0] != "staging" {
+		t.Errorf("expect the staging example, got %v", staging["getConfig"])
+	}
+	if staging["getStatus"][0] != "default" {
+		t.Errorf("expect the default example without a staging value, got %v", staging["getStatus"])
+	}
+	if dev := sources(Options{Environment: "dev"}); dev["getStatus"][0] != "dev" {
+		t.Errorf("expect the dev example of the media type, got %v", dev["getStatus"])
+	}
+	if none := sources(Options{}); none["getConfig"][0] != "default" {
+		t.Errorf("expect the default example without environment, got %v", none["getConfig"])
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// errorCatalogEntry is a distinct error response and the operations returning it.
+type errorCatalogEntry struct {
+	Code        int         `json:"code"`
+	Description string      `json:"description"`
+	Body        interface{} `json:"body,omitempty"`
+	Operations  []string    `json:"operations"`
+}
+
+// errorCatalog lists the distinct 4XX and 5XX responses of the requests, by
+// code, description and body, sorted by code.
+func (m *MockServerSetting) errorCatalog() []*errorCatalogEntry {
+	catalog := []*errorCatalogEntry{}
+	entries := map[string]*errorCatalogEntry{}
+	for _, request := range m.Requests {
+		operation := fmt.Sprintf("%s %s", request.Method, request.Path)
+		for _, response := range request.Responses {
+			if response.Code < 400 || response.Code > 599 {
+				continue
+			}
+			body := ""
+			if response.Body != nil {
+				body = *response.Body
+			}
+
+			key := fmt.Sprintf("%d\x00%s\x00%s", response.Code, response.Description, body)
+			entry, ok := entries[key]
+			if !ok {
+				entry = &errorCatalogEntry{Code: response.Code, Description: response.Description, Operations: []string{}}
+				if json.Valid([]byte(body)) {
+					entry.Body = json.RawMessage(body)
+				} else if body != "" {
+					entry.Body = body
+				}
+				entries[key] = entry
+				catalog = append(catalog, entry)
+			}
+			if len(entry.Operations) == 0 || entry.Operations[len(entry.Operations)-1] != operation {
+				entry.Operations = append(entry.Operations, operation)
+			}
+		}
+	}
+
+	sort.SliceStable(catalog, func(i, j int) bool {
+		if catalog[i].Code != catalog[j].Code {
+			return catalog[i].Code < catalog[j].Code
+		}
+		return catalog[i].Description < catalog[j].Description
+	})
+	for _, entry := range catalog {
+		sort.Strings(entry.Operations)
+	}
+	return catalog
+}
+
+// saveErrorCatalog writes the catalog of the error responses into errors.json.
+func (m *MockServerSetting) saveErrorCatalog() error {
+	data, err := json.MarshalIndent(m.errorCatalog(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal error catalog: %w", err)
+	}
+	errorsFilePath := fmt.Sprintf("%s/errors.json", m.Folder)
+	if err := os.WriteFile(errorsFilePath, data, m.Options.fileMode()); err != nil {
+		return fmt.Errorf("failed to write error catalog to file: %w", err)
+	}
+	infof("Error catalog is saved to %s", errorsFilePath)
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveErrorCatalog(t *testing.T) {
+	setting := generateTestMock(t, `
+openapi: "3.0.0"
+info:
+  title: Pet API
+  version: 1.0.0
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      responses:
+        '200':
+          description: OK
+        '404':
+          $ref: "#/components/responses/NotFound"
+        '500':
+          description: Server Error
+          content:
+            application/json:
+              example: {"error": "internal"}
+  /owners/{id}:
+    get:
+      operationId: getOwner
+      responses:
+        '404':
+          $ref: "#/components/responses/NotFound"
+        '400':
+          description: Bad Request
+components:
+  responses:
+    NotFound:
+      description: Not Found
+      content:
+        application/json:
+          example: {"error": "not found"}
+`, Options{EmitErrors: true})
+
+	data, err := os.ReadFile(filepath.Join(setting.Folder, "errors.json"))
+	if err != nil {
+		t.Fatalf("Failed to read errors.json: %v", err)
+	}
+	var catalog []struct {
+		Code        int                    `json:"code"`
+		Description string                 `json:"description"`
+		Body        map[string]interface{} `json:"body"`
+		Operations  []string               `json:"operations"`
+	}
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		t.Fatalf("Invalid errors.json: %v", err)
+	}
+
+	if len(catalog) != 3 {
+		t.Fatalf("expect 3 distinct errors, got:\n%s", data)
+	}
+	if catalog[0].Code != 400 || catalog[0].Description != "Bad Request" || catalog[0].Body != nil {
+		t.Errorf("unexpected 400 entry %+v", catalog[0])
+	}
+	notFound := catalog[1]
+	if notFound.Code != 404 || notFound.Body["error"] != "not found" ||
+		strings.Join(notFound.Operations, ",") != "GET /owners/{id},GET /pets/{id}" {
+		t.Errorf("expect the shared 404 once with both operations, got %+v", notFound)
+	}
+	if catalog[2].Code != 500 || catalog[2].Body["error"] != "internal" {
+		t.Errorf("unexpected 500 entry %+v", catalog[2])
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"mime"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// isJSONContentType reports whether a content type is JSON, e.g.
+// application/json or application/problem+json.
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// isYAMLContentType reports whether a content type is YAML, e.g.
+// application/yaml or application/vnd.api+yaml.
+func isYAMLContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case "application/yaml", "application/x-yaml", "text/yaml":
+		return true
+	}
+	return strings.HasSuffix(mediaType, "+yaml")
+}
+
+// exampleViolations validates the examples of the JSON responses of the spec
+// against their schemas, and describes each example violating its schema.
+func exampleViolations(openAPISpec openapi3.T) []string {
+	violations := []string{}
+	for path, pathItem := range openAPISpec.Paths.Map() {
+		for method, operation := range pathItem.Operations() {
+			if operation.Responses == nil {
+				continue
+			}
+			for code, response := range operation.Responses.Map() {
+				if response.Value == nil {
+					continue
+				}
+				for contentType, mediaType := range response.Value.Content {
+					if mediaType == nil || mediaType.Schema == nil || mediaType.Schema.Value == nil || !isJSONContentType(contentType) {
+						continue
+					}
+					location := fmt.Sprintf("%s %s %s %s", method, path, code, contentType)
+					examples := map[string]interface{}{}
+					if mediaType.Example != nil {
+						examples["example"] = mediaType.Example
+					}
+					for name, example := range mediaType.Examples {
+						if example != nil && example.Value != nil && example.Value.Value != nil {
+							examples["examples/"+name] = example.Value.Value
+						}
+					}
+					for name, value := range examples {
+						if err := mediaType.Schema.Value.VisitJSON(value); err != nil {
+							reason, _, _ := strings.Cut(err.Error(), "\n")
+							violations = append(violations, fmt.Sprintf("%s %s: %s", location, name, reason))
+						}
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(violations)
+	return violations
+}
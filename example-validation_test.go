@@ -0,0 +1,56 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const invalidExampleSpec = `
+openapi: "3.0.0"
+info:
+  title: Strict API
+  version: 1.0.0
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  age:
+                    type: integer
+              examples:
+                valid:
+                  value: {"age": 3}
+                invalid:
+                  value: {"age": "three"}
+`
+
+func TestExampleViolations(t *testing.T) {
+	violations := exampleViolations(loadTestSpec(t, invalidExampleSpec))
+	if len(violations) != 1 || !strings.HasPrefix(violations[0], "GET /pets/{id} 200 application/json examples/invalid: ") {
+		t.Errorf("expect the invalid example to be reported, got %v", violations)
+	}
+}
+
+func TestExportStrictExamples(t *testing.T) {
+	openApiFile := filepath.Join(t.TempDir(), "strict.yaml")
+	if err := os.WriteFile(openApiFile, []byte(invalidExampleSpec), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+
+	if err := exportOpenAPIToMockServer(openApiFile, []string{t.TempDir()}, Options{}); err != nil {
+		t.Errorf("expect invalid examples to be accepted by default, got %v", err)
+	}
+	err := exportOpenAPIToMockServer(openApiFile, []string{t.TempDir()}, Options{StrictExamples: true})
+	if err == nil || exitCode(err) != ExitValidation {
+		t.Errorf("expect a validation error in strict examples mode, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import "errors"
+
+// Exit codes of the command, by category of failure, so that pipelines can
+// branch on the cause.
+const (
+	ExitOK           = 0 // the generation succeeded
+	ExitFailure      = 1 // any other failure, e.g. a failed post-generation hook
+	ExitUsage        = 2 // invalid command line arguments
+	ExitSpecNotFound = 3 // the OpenAPI file does not exist or cannot be fetched
+	ExitParse        = 4 // the OpenAPI file cannot be read or parsed
+	ExitValidation   = 5 // the OpenAPI file is rejected, e.g. an unsupported version
+	ExitWrite        = 6 // the mock server cannot be written
+)
+
+// exitError is an error carrying the exit code of its category.
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// withExitCode tags an error with the exit code of its category.
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+// exitCode returns the exit code of an error, ExitFailure when it has no
+// category.
+func exitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+	return ExitFailure
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Formats of the scripts exported from the routes.
+const (
+	ExportK6 = "k6" // k6 load-test script, loadtest.js
+)
+
+// parseExport validates the format of the exported script.
+func parseExport(value string) (string, error) {
+	switch value {
+	case ExportK6:
+		return value, nil
+	}
+	return "", fmt.Errorf("invalid export format %q, expected %s", value, ExportK6)
+}
+
+// pathParameterPattern matches the parameters of a path template, e.g. {id}.
+var pathParameterPattern = regexp.MustCompile(`\{[^}]*\}`)
+
+// baseURL returns the URL clients reach the mock server at.
+func (m *MockServerSetting) baseURL() string {
+	host := m.Host
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(m.Port))
+}
+
+// k6Script returns a k6 load-test script calling each route of the mock server
+// once per iteration, with a sample request body when the operation has one.
+func (m *MockServerSetting) k6Script() string {
+	var script strings.Builder
+	script.WriteString("import http from 'k6/http';\n")
+	script.WriteString("import { check, sleep } from 'k6';\n\n")
+	fmt.Fprintf(&script, "const BASE_URL = __ENV.BASE_URL || %s;\n\n", jsString(m.baseURL()))
+	script.WriteString("export const options = {\n  vus: 1,\n  duration: '30s',\n};\n\n")
+	script.WriteString("export default function () {\n  let res;\n")
+
+	for _, request := range m.Requests {
+		path := pathParameterPattern.ReplaceAllString(request.Path, "1")
+		fmt.Fprintf(&script, "\n  // %s %s\n", request.Method, request.Path)
+
+		body, contentType := "null", ""
+		if sample, sampleType, ok := requestBodySample(request.Operation, m.Schemas, m.Options); ok {
+			body, contentType = jsString(sample), sampleType
+		}
+		params := "{}"
+		if contentType != "" {
+			params = fmt.Sprintf("{ headers: { 'Content-Type': %s } }", jsString(contentType))
+		}
+		fmt.Fprintf(&script, "  res = http.request(%s, `${BASE_URL}%s`, %s, %s);\n",
+			jsString(request.Method), strings.ReplaceAll(path, "`", "\\`"), body, params)
+
+		if len(request.Responses) > 0 {
+			code := request.Responses[0].Code
+			fmt.Fprintf(&script, "  check(res, { %s: (r) => r.status === %d });\n",
+				jsString(fmt.Sprintf("%s %s is %d", request.Method, request.Path, code)), code)
+		}
+	}
+
+	script.WriteString("\n  sleep(1);\n}\n")
+	return script.String()
+}
+
+// requestBodySample returns a sample body of the request body of an operation,
+// and its content type. JSON content is preferred.
+func requestBodySample(operation *openapi3.Operation, schemaExamples map[string]string, opts Options) (string, string, bool) {
+	if operation == nil || operation.RequestBody == nil || operation.RequestBody.Value == nil {
+		return "", "", false
+	}
+	content := operation.RequestBody.Value.Content
+	contentType := "application/json"
+	if content.Get(contentType) == nil {
+		contentType = ""
+		for candidate := range content {
+			if contentType == "" || candidate < contentType {
+				contentType = candidate
+			}
+		}
+	}
+	mediaType := content.Get(contentType)
+	if mediaType == nil {
+		return "", "", false
+	}
+
+	// The named examples are tried in the order of their names, so that the
+	// sample is the same on each run
+	body := exampleBodyString(mediaType.Example)
+	for _, name := range sortedKeys(mediaType.Examples) {
+		if body == "" {
+			body = getBodyString(mediaType.Examples[name])
+		}
+	}
+	if body == "" && mediaType.Schema != nil {
+		body = schemaBodyString(mediaType.Schema, schemaExamples, opts)
+	}
+	if body == "" {
+		return "", "", false
+	}
+	return body, contentType, true
+}
+
+// jsString quotes a string as a JavaScript string literal.
+func jsString(value string) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// saveExport writes the script of the export format into the mock server folder.
+func (m *MockServerSetting) saveExport() error {
+	switch m.Options.Export {
+	case ExportK6:
+		scriptFilePath := fmt.Sprintf("%s/loadtest.js", m.Folder)
+		if err := os.WriteFile(scriptFilePath, []byte(m.k6Script()), m.Options.fileMode()); err != nil {
+			return fmt.Errorf("failed to write k6 script to file: %w", err)
+		}
+		infof("k6 script is saved to %s", scriptFilePath)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveExportK6(t *testing.T) {
+	setting := generateTestMock(t, `
+openapi: "3.0.0"
+info:
+  title: Pet API
+  version: 1.0.0
+servers:
+  - url: http://localhost:8080
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      requestBody:
+        content:
+          application/json:
+            example: {"name": "Tom"}
+      responses:
+        '201':
+          description: Created
+  /pets/{id}:
+    get:
+      operationId: getPet
+      responses:
+        '200':
+          description: OK
+        '404':
+          description: Not Found
+`, Options{Export: ExportK6})
+
+	data, err := os.ReadFile(filepath.Join(setting.Folder, "loadtest.js"))
+	if err != nil {
+		t.Fatalf("Failed to read loadtest.js: %v", err)
+	}
+	script := string(data)
+	for _, expected := range []string{
+		`const BASE_URL = __ENV.BASE_URL || "http://localhost:8080";`,
+		"res = http.request(\"POST\", `${BASE_URL}/pets`, \"{\\n  \\\"name\\\": \\\"Tom\\\"\\n}\", { headers: { 'Content-Type': \"application/json\" } });",
+		"res = http.request(\"GET\", `${BASE_URL}/pets/1`, null, {});",
+		`check(res, { "GET /pets/{id} is 200": (r) => r.status === 200 });`,
+		`check(res, { "POST /pets is 201": (r) => r.status === 201 });`,
+	} {
+		if !strings.Contains(script, expected) {
+			t.Errorf("expect script to contain %s, got:\n%s", expected, script)
+		}
+	}
+
+	if _, err := parseExport("jmeter"); err == nil {
+		t.Errorf("expect error for an unknown export format")
+	}
+}
+
+func TestRequestBodySampleNamedExamples(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Pet API
+  version: 1.0.0
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      requestBody:
+        content:
+          application/json:
+            examples:
+              tom:
+                value: {"name": "Tom"}
+              jerry:
+                value: {"name": "Jerry"}
+      responses:
+        '201':
+          description: Created
+`)
+	operation := spec.Paths.Find("/pets").Post
+	for i := 0; i < 10; i++ {
+		body, _, ok := requestBodySample(operation, nil, Options{})
+		if !ok || !strings.Contains(body, "Jerry") {
+			t.Fatalf("expect the example with the lowest name as sample, got %q", body)
+		}
+	}
+}
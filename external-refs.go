@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
+)
+
+// maxRootRefs is the maximum number of root documents followed by
+// followRootRef, to stop on reference cycles.
+const maxRootRefs = 8
+
+// followRootRef replaces a root document that is only a $ref to another
+// document, e.g. `$ref: https://example.com/openapi.yaml`, by the referred
+// document, along with its location.
+func followRootRef(loader *openapi3.Loader, data []byte, location *url.URL) ([]byte, *url.URL, error) {
+	for i := 0; i < maxRootRefs; i++ {
+		ref := rootRef(data)
+		if ref == "" {
+			return data, location, nil
+		}
+		refURL, err := url.Parse(ref)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid root reference %q: %w", ref, err)
+		}
+		location = location.ResolveReference(refURL)
+		location.Fragment = ""
+		if data, err = openapi3.DefaultReadFromURI(loader, location); err != nil {
+			return nil, nil, fmt.Errorf("failed to read the root reference %s: %w", location, err)
+		}
+		if data, err = decodeSpecData(data); err != nil {
+			return nil, nil, err
+		}
+	}
+	return nil, nil, fmt.Errorf("too many root references, stopped at %s", location)
+}
+
+// rootRef returns the reference of a document made of a single $ref, or an
+// empty string.
+func rootRef(data []byte) string {
+	var document map[string]interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil || len(document) != 1 {
+		return ""
+	}
+	ref, _ := document["$ref"].(string)
+	return ref
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseOpenApiFileExternalRefs(t *testing.T) {
+	folder := t.TempDir()
+	files := map[string]string{
+		"root.yaml": "$ref: ./specs/api.yaml\n",
+		"specs/api.yaml": `
+openapi: "3.0.0"
+info:
+  title: Remote API
+  version: 1.0.0
+paths:
+  /pets:
+    $ref: ./paths.yaml#/pets
+`,
+		"specs/paths.yaml": `
+pets:
+  get:
+    operationId: listPets
+    responses:
+      '200':
+        description: OK
+        content:
+          application/json:
+            schema:
+              $ref: ./schemas.yaml#/Pet
+`,
+		"specs/schemas.yaml": `
+Pet:
+  type: object
+  properties:
+    name:
+      type: string
+      example: Tom
+`,
+	}
+	for name, content := range files {
+		path := filepath.Join(folder, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create folder: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	// External references are only resolved on demand
+	if _, err := ParseOpenApiFile(filepath.Join(folder, "root.yaml")); err == nil {
+		t.Errorf("expect the root reference rejected without external refs")
+	}
+	if _, err := ParseOpenApiFile(filepath.Join(folder, "specs", "api.yaml")); err == nil {
+		t.Errorf("expect the external references rejected without external refs")
+	}
+	if err := Generate(filepath.Join(folder, "root.yaml"), t.TempDir(), Options{}); err == nil {
+		t.Errorf("expect the generation to fail without external refs")
+	}
+	if err := Generate(filepath.Join(folder, "root.yaml"), t.TempDir(), Options{ExternalRefs: true}); err != nil {
+		t.Errorf("expect the generation to succeed with external refs, got %v", err)
+	}
+
+	spec, err := parseOpenApiFile(filepath.Join(folder, "root.yaml"), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	setting := ConvertOpenAPIToMockServer(spec, Options{})
+	if setting.Name != "Remote API" || len(setting.Requests) != 1 || setting.Requests[0].Name != "listPets" {
+		t.Fatalf("expect the referred spec, got %s with %+v", setting.Name, setting.Requests)
+	}
+	if body := setting.Requests[0].Responses[0].Body; body == nil || *body != "{\n  \"name\": \"Tom\"\n}" {
+		t.Errorf("expect the body of the external schema, got %v", body)
+	}
+}
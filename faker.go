@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// fakerFunc generates a fake value for a schema property from a random source.
+type fakerFunc func(r *rand.Rand) interface{}
+
+var (
+	fakeFirstNames = []string{"John", "Jane", "Alice", "Bob", "Carol", "David", "Emma", "Frank"}
+	fakeLastNames  = []string{"Smith", "Johnson", "Brown", "Taylor", "Miller", "Wilson", "Moore", "Clark"}
+	fakeDomains    = []string{"example.com", "example.org", "example.net"}
+	fakeCities     = []string{"Springfield", "Riverside", "Fairview", "Franklin", "Greenville", "Madison"}
+	fakeCountries  = []string{"United States", "Canada", "Germany", "France", "Japan", "Vietnam"}
+	fakeStreets    = []string{"Main St", "Oak Ave", "Pine Rd", "Maple Dr", "Cedar Ln", "Elm St"}
+	fakeCompanies  = []string{"Acme Corp", "Globex", "Initech", "Umbrella", "Stark Industries", "Wayne Enterprises"}
+	fakeWords      = []string{"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit"}
+)
+
+// fakers maps the names accepted by the `x-faker` vendor extension to their generators.
+var fakers = map[string]fakerFunc{
+	"name.firstName": func(r *rand.Rand) interface{} { return pick(r, fakeFirstNames) },
+	"name.lastName":  func(r *rand.Rand) interface{} { return pick(r, fakeLastNames) },
+	"name.fullName": func(r *rand.Rand) interface{} {
+		return pick(r, fakeFirstNames) + " " + pick(r, fakeLastNames)
+	},
+	"internet.email": func(r *rand.Rand) interface{} {
+		return strings.ToLower(pick(r, fakeFirstNames)+"."+pick(r, fakeLastNames)) + "@" + pick(r, fakeDomains)
+	},
+	"internet.userName": func(r *rand.Rand) interface{} {
+		return strings.ToLower(pick(r, fakeFirstNames)) + fmt.Sprintf("%d", r.Intn(100))
+	},
+	"internet.url": func(r *rand.Rand) interface{} { return "https://www." + pick(r, fakeDomains) },
+	"internet.ipv4": func(r *rand.Rand) interface{} {
+		return fmt.Sprintf("192.168.%d.%d", r.Intn(256), 1+r.Intn(254))
+	},
+	"phone.number": func(r *rand.Rand) interface{} {
+		return fmt.Sprintf("+1-555-%03d-%04d", r.Intn(1000), r.Intn(10000))
+	},
+	"address.city":          func(r *rand.Rand) interface{} { return pick(r, fakeCities) },
+	"address.country":       func(r *rand.Rand) interface{} { return pick(r, fakeCountries) },
+	"address.streetAddress": func(r *rand.Rand) interface{} { return fmt.Sprintf("%d %s", 1+r.Intn(9999), pick(r, fakeStreets)) },
+	"address.zipCode":       func(r *rand.Rand) interface{} { return fmt.Sprintf("%05d", r.Intn(100000)) },
+	"company.name":          func(r *rand.Rand) interface{} { return pick(r, fakeCompanies) },
+	"lorem.word":            func(r *rand.Rand) interface{} { return pick(r, fakeWords) },
+	"lorem.sentence": func(r *rand.Rand) interface{} {
+		words := make([]string, 6)
+		for i := range words {
+			words[i] = pick(r, fakeWords)
+		}
+		sentence := strings.Join(words, " ")
+		return strings.ToUpper(sentence[:1]) + sentence[1:] + "."
+	},
+	"datatype.uuid": func(r *rand.Rand) interface{} {
+		return fmt.Sprintf("%08x-%04x-4%03x-8%03x-%012x",
+			r.Uint32(), r.Intn(0x10000), r.Intn(0x1000), r.Intn(0x1000), r.Int63n(0x1000000000000))
+	},
+	"datatype.number":  func(r *rand.Rand) interface{} { return r.Intn(1000) },
+	"datatype.boolean": func(r *rand.Rand) interface{} { return r.Intn(2) == 1 },
+}
+
+// pick returns a random element of the given list.
+func pick(r *rand.Rand, list []string) string {
+	return list[r.Intn(len(list))]
+}
+
+// fakeValue returns a value generated by the named faker.
+// The second return value is false if the faker is unknown.
+func fakeValue(name string, r *rand.Rand) (interface{}, bool) {
+	faker, ok := fakers[name]
+	if !ok {
+		return nil, false
+	}
+	return faker(r), true
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// fixtureExtension is the extension of a response naming the shared fixture
+// its body comes from, e.g. currentUser, so that the responses naming the same
+// fixture serve the same body, written once.
+const fixtureExtension = "x-mock-fixture"
+
+// fixturesFolderName is the name of the folder the fixtures are written into,
+// under the mock server folder.
+const fixturesFolderName = "fixtures"
+
+// responseFixture returns the name of the fixture of a response, from its
+// fixture extension. It is empty without the extension.
+func responseFixture(response *openapi3.Response) (string, error) {
+	raw, ok := response.Extensions[fixtureExtension]
+	if !ok {
+		return "", nil
+	}
+	name, ok := raw.(string)
+	if !ok || cleanFolderName(name) == "" {
+		return "", fmt.Errorf("invalid %s %v, expected a fixture name", fixtureExtension, raw)
+	}
+	return name, nil
+}
+
+// shareFixtures gives the responses naming a fixture the body of the fixture:
+// the body of the first of them with one, by path, method and code.
+func shareFixtures(requests []Request) {
+	type fixtureResponse struct {
+		request, response int
+	}
+	fixtures := map[string][]fixtureResponse{}
+	for i, request := range requests {
+		for j, response := range request.Responses {
+			if response.Fixture != "" {
+				fixtures[response.Fixture] = append(fixtures[response.Fixture], fixtureResponse{i, j})
+			}
+		}
+	}
+
+	for name, responses := range fixtures {
+		sort.SliceStable(responses, func(a, b int) bool {
+			x, y := requests[responses[a].request], requests[responses[b].request]
+			if x.Path != y.Path {
+				return x.Path < y.Path
+			}
+			if x.Method != y.Method {
+				return x.Method < y.Method
+			}
+			return x.Responses[responses[a].response].Code < y.Responses[responses[b].response].Code
+		})
+
+		var fixture *Response
+		for _, r := range responses {
+			if response := &requests[r.request].Responses[r.response]; response.Body != nil {
+				fixture = response
+				break
+			}
+		}
+		if fixture == nil {
+			warnf("Fixture %s has no body, skipped", name)
+			continue
+		}
+		for _, r := range responses {
+			response := &requests[r.request].Responses[r.response]
+			response.Body = fixture.Body
+			if response.ContentType() == "" && fixture.Headers != nil {
+				response.Headers = &[]Header{{Name: "Content-Type", Value: fixture.ContentType()}}
+			}
+		}
+	}
+}
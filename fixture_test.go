@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const fixtureSpec = `
+openapi: "3.0.0"
+info:
+  title: Fixture API
+  version: 1.0.0
+paths:
+  /me:
+    get:
+      responses:
+        "200":
+          description: OK
+          x-mock-fixture: currentUser
+          content:
+            application/json:
+              example:
+                name: Tom
+  /session:
+    get:
+      responses:
+        "200":
+          description: OK
+          x-mock-fixture: currentUser
+          content:
+            application/json:
+              example:
+                name: Jerry
+`
+
+func TestFixtures(t *testing.T) {
+	targetFolder := t.TempDir()
+	setting, _ := generateInto(t, targetFolder, fixtureSpec, Options{Port: 8080})
+
+	filePaths := map[string]bool{}
+	for _, request := range setting.Requests {
+		response := request.Responses[0]
+		if response.FilePath == nil {
+			t.Fatalf("Expected %s to have a body file", request.Path)
+		}
+		filePaths[*response.FilePath] = true
+	}
+	if len(filePaths) != 1 {
+		t.Fatalf("Expected the responses to share the fixture file, got %v", filePaths)
+	}
+	for filePath := range filePaths {
+		if !strings.HasSuffix(filePath, "/fixtures/currentUser.json") {
+			t.Errorf("Expected the fixture under the fixtures folder, got %s", filePath)
+		}
+		body, err := os.ReadFile(filepath.Join(targetFolder, filePath))
+		if err != nil {
+			t.Fatalf("Failed to read fixture: %v", err)
+		}
+		if !strings.Contains(string(body), "Tom") {
+			t.Errorf("Expected the fixture from the first response, got %s", body)
+		}
+	}
+
+	entries, err := os.ReadDir(filepath.Join(setting.Folder, fixturesFolderName))
+	if err != nil {
+		t.Fatalf("Failed to read fixtures folder: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected the fixture written once, got %d files", len(entries))
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// keyValueFlag is a repeatable command line flag of key=value pairs.
+type keyValueFlag map[string]string
+
+func (f keyValueFlag) String() string {
+	pairs := []string{}
+	for key, value := range f {
+		pairs = append(pairs, key+"="+value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (f keyValueFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || strings.TrimSpace(key) == "" {
+		return fmt.Errorf("expected key=value, got %q", value)
+	}
+	f[strings.TrimSpace(key)] = strings.TrimSpace(val)
+	return nil
+}
+
+// stringListFlag is a repeatable command line flag collecting its values.
+type stringListFlag []string
+
+func (f *stringListFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringListFlag) Set(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("empty value")
+	}
+	*f = append(*f, value)
+	return nil
+}
+
+// parseCodes parses a comma separated list of response codes, e.g. "200,201,204".
+func parseCodes(value string) ([]int, error) {
+	codes := []int{}
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		code, err := strconv.Atoi(item)
+		if err != nil || code < 100 || code > 599 {
+			return nil, fmt.Errorf("invalid response code %q", item)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
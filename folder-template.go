@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Tokens of the folder template, replaced when creating the mock server folder.
+const (
+	folderTokenName    = "{name}"    // the title of the spec
+	folderTokenVersion = "{version}" // the version of the spec
+	folderTokenDate    = "{date}"    // the date of the generation, e.g. 20240101
+)
+
+// folderTokenPattern matches the tokens of a folder template.
+var folderTokenPattern = regexp.MustCompile(`\{[^{}]*\}`)
+
+// parseFolderTemplate validates a folder template, e.g. "{name}-v{version}-{date}".
+func parseFolderTemplate(value string) (string, error) {
+	for _, token := range folderTokenPattern.FindAllString(value, -1) {
+		switch token {
+		case folderTokenName, folderTokenVersion, folderTokenDate:
+		default:
+			return "", fmt.Errorf("invalid folder template token %s, expected %s, %s or %s", token, folderTokenName, folderTokenVersion, folderTokenDate)
+		}
+	}
+	if strings.ContainsAny(folderTokenPattern.ReplaceAllString(value, ""), "{}") {
+		return "", fmt.Errorf("invalid folder template %q, unbalanced braces", value)
+	}
+	return value, nil
+}
+
+// folderName returns the name of the mock server folder: the cleaned name of
+// the spec, or the folder template with its tokens replaced and sanitized.
+func (m *MockServerSetting) folderName() string {
+	name := cleanFolderName(m.Name)
+	if m.Options.FolderTemplate == "" {
+		return name
+	}
+
+	version := ""
+	if m.Spec != nil && m.Spec.Info != nil {
+		version = m.Spec.Info.Version
+	}
+	folderName := strings.NewReplacer(
+		folderTokenName, m.Name,
+		folderTokenVersion, version,
+		folderTokenDate, time.Now().Format("20060102"),
+	).Replace(m.Options.FolderTemplate)
+	folderName = cleanFolderName(folderName)
+	if folderName == "" || strings.Trim(folderName, ".") == "" {
+		return name
+	}
+	return folderName
+}
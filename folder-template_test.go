@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCreateFolderTemplate(t *testing.T) {
+	template, err := parseFolderTemplate("{name}-v{version}-{date}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	setting := generateTestMock(t, `
+openapi: "3.0.0"
+info:
+  title: My API
+  version: "2"
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              example: [{"name": "Tom"}]
+`, Options{FolderTemplate: template})
+
+	expected := "My_API-v2-" + time.Now().Format("20060102")
+	if name := filepath.Base(setting.Folder); name != expected {
+		t.Errorf("expect folder %s, got %s", expected, name)
+	}
+	for _, request := range setting.Requests {
+		for _, response := range request.Responses {
+			if response.FilePath == nil {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(setting.TargetFolder, *response.FilePath)); err != nil {
+				t.Errorf("expect the recorded body file %s to exist: %v", *response.FilePath, err)
+			}
+		}
+	}
+
+	for _, value := range []string{"{name}-{build}", "{name", "v}"} {
+		if _, err := parseFolderTemplate(value); err == nil {
+			t.Errorf("expect error for %q", value)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// fragmentFileName is the name of the OpenAPI fragment written into each request folder.
+const fragmentFileName = "openapi.json"
+
+// operationFragment returns a standalone OpenAPI document describing only the
+// operation of a request. The components of the spec are kept so that the
+// references of the operation still resolve.
+func operationFragment(spec *openapi3.T, request Request) *openapi3.T {
+	pathItem := &openapi3.PathItem{}
+	pathItem.SetOperation(request.Method, request.Operation)
+	return &openapi3.T{
+		OpenAPI:    spec.OpenAPI,
+		Info:       spec.Info,
+		Paths:      openapi3.NewPaths(openapi3.WithPath(request.Path, pathItem)),
+		Components: spec.Components,
+	}
+}
+
+// saveFragments writes the OpenAPI fragment of each request into its folder.
+// Requests without an operation, like the health check, are skipped.
+func (m *MockServerSetting) saveFragments() error {
+	for _, request := range m.Requests {
+		if request.Operation == nil || m.Spec == nil {
+			continue
+		}
+		data, err := json.MarshalIndent(operationFragment(m.Spec, request), "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal OpenAPI fragment: %w", err)
+		}
+
+		folderFullPath := fmt.Sprintf("%s/%s/%s", m.Folder, request.Method, requestFolderName(request))
+		if err := ensureFolder(folderFullPath, m.Options.dirMode()); err != nil {
+			return fmt.Errorf("failed to create request folder: %w", err)
+		}
+		fragmentFilePath := fmt.Sprintf("%s/%s", folderFullPath, fragmentFileName)
+		if err := os.WriteFile(fragmentFilePath, data, m.Options.fileMode()); err != nil {
+			return fmt.Errorf("failed to write OpenAPI fragment to file: %w", err)
+		}
+		infof("OpenAPI fragment is saved to %s", fragmentFilePath)
+	}
+	return nil
+}
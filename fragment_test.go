@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func TestSaveFragments(t *testing.T) {
+	setting := generateTestMock(t, `
+openapi: "3.0.0"
+info:
+  title: Pet API
+  version: 1.0.0
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: string
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Pet"
+  /owners:
+    get:
+      operationId: listOwners
+      responses:
+        "204":
+          description: No Content
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name:
+          type: string
+          example: Tom
+`, Options{EmitFragments: true, EmitHealth: true, HealthPath: "/healthz"})
+
+	fragmentPath := filepath.Join(setting.Folder, "GET", "getPet", fragmentFileName)
+	loader := openapi3.NewLoader()
+	fragment, err := loader.LoadFromFile(fragmentPath)
+	if err != nil {
+		t.Fatalf("Failed to load fragment: %v", err)
+	}
+	if err := fragment.Validate(context.Background()); err != nil {
+		t.Errorf("expect a valid standalone document, got %v", err)
+	}
+	if fragment.Paths.Len() != 1 || fragment.Paths.Find("/pets/{id}") == nil {
+		t.Errorf("expect only the operation path, got %v", fragment.Paths.InMatchingOrder())
+	}
+	operation := fragment.Paths.Find("/pets/{id}").Get
+	if operation == nil || operation.OperationID != "getPet" {
+		t.Fatalf("expect the getPet operation in the fragment")
+	}
+	schema := operation.Responses.Status(200).Value.Content["application/json"].Schema
+	if schema.Value == nil || schema.Value.Properties["name"] == nil {
+		t.Errorf("expect the schema reference to resolve in the fragment")
+	}
+
+	if _, err := os.Stat(filepath.Join(setting.Folder, "GET", "listOwners", fragmentFileName)); err != nil {
+		t.Errorf("expect a fragment for a request without body files: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(setting.Folder, "GET", "healthCheck", fragmentFileName)); !os.IsNotExist(err) {
+		t.Errorf("expect no fragment for the health check")
+	}
+}
@@ -0,0 +1,13 @@
+package main
+
+// Generate converts an OpenAPI file into a mock server written into the target
+// folder, the way the command does. The options collect all the behavior
+// toggles, e.g. host, port, seed, example mode and filters; their zero value
+// gives the defaults of the command. A JSON Pointer fragment of the file, e.g.
+// spec.yaml#/paths/~1users, generates only that part of the spec.
+func Generate(openApiFile string, targetFolder string, opts Options) error {
+	if opts.SpecPointer == "" {
+		openApiFile, opts.SpecPointer = splitSpecPointer(openApiFile)
+	}
+	return exportOpenAPIToMockServer(openApiFile, []string{targetFolder}, opts)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestGenerate(t *testing.T) {
+	openApiFile := filepath.Join(t.TempDir(), "pets.yaml")
+	if err := os.WriteFile(openApiFile, []byte(petSpec), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	target := t.TempDir()
+	seed := int64(7)
+	opts := Options{Host: "127.0.0.1", Port: 9000, Seed: &seed, SettingName: "mock.yaml", Codes: []int{200}, NoCopySpec: true}
+	if err := Generate(openApiFile, target, opts); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(target, "data", "Pet_API", "mock.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read setting: %v", err)
+	}
+	var setting MockServerSetting
+	if err := yaml.Unmarshal(data, &setting); err != nil {
+		t.Fatalf("Failed to parse setting: %v", err)
+	}
+	if setting.Host != "127.0.0.1" || setting.Port != 9000 {
+		t.Errorf("expect the host and port of the options, got %s:%d", setting.Host, setting.Port)
+	}
+	for _, request := range setting.Requests {
+		for _, response := range request.Responses {
+			if response.Code != 200 {
+				t.Errorf("expect only the 200 responses, got %d for %s", response.Code, request.Name)
+			}
+		}
+	}
+	if _, err := os.Stat(filepath.Join(target, "data", "Pet_API", "openapi.yaml")); !os.IsNotExist(err) {
+		t.Errorf("expect the spec not to be copied")
+	}
+
+	if err := Generate(filepath.Join(target, "missing.yaml"), target, Options{}); exitCode(err) != ExitSpecNotFound {
+		t.Errorf("expect a spec not found error, got %v", err)
+	}
+}
+
+func TestGenerateQuiet(t *testing.T) {
+	openApiFile := filepath.Join(t.TempDir(), "pets.yaml")
+	if err := os.WriteFile(openApiFile, []byte(petSpec), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	if err := Generate(openApiFile, t.TempDir(), Options{Quiet: true}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output.Len() != 0 {
+		t.Errorf("expect no progress logged, got:\n%s", output.String())
+	}
+	if logLevel != levelInfo {
+		t.Errorf("expect the log level restored, got %d", logLevel)
+	}
+
+	if err := Generate(openApiFile, t.TempDir(), Options{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if output.Len() == 0 {
+		t.Errorf("expect the progress logged without quiet")
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// gitSourcePrefix marks an OpenAPI source stored in a git repository, e.g.
+// git::https://github.com/org/repo//path/spec.yaml?ref=main
+const gitSourcePrefix = "git::"
+
+// gitTokenEnv is the environment variable holding the token used to authenticate
+// against https git repositories.
+const gitTokenEnv = "MOCK_GIT_TOKEN"
+
+// gitSource is a parsed git reference to an OpenAPI file.
+type gitSource struct {
+	Repo string // URL of the repository
+	Path string // path of the file inside the repository
+	Ref  string // branch or tag, empty for the default branch
+}
+
+// parseGitSource parses a git::<repo-url>//<path>?ref=<ref> reference.
+func parseGitSource(source string) (gitSource, error) {
+	if !strings.HasPrefix(source, gitSourcePrefix) {
+		return gitSource{}, fmt.Errorf("not a git source: %s", source)
+	}
+	rest := strings.TrimPrefix(source, gitSourcePrefix)
+
+	ref := ""
+	if i := strings.LastIndex(rest, "?"); i >= 0 {
+		query := rest[i+1:]
+		rest = rest[:i]
+		for _, param := range strings.Split(query, "&") {
+			if value, ok := strings.CutPrefix(param, "ref="); ok {
+				ref = value
+			}
+		}
+	}
+
+	// The file path is separated from the repository URL by a double slash,
+	// skipping the one of the URL scheme
+	offset := 0
+	if i := strings.Index(rest, "://"); i >= 0 {
+		offset = i + len("://")
+	}
+	i := strings.Index(rest[offset:], "//")
+	if i < 0 {
+		return gitSource{}, fmt.Errorf("missing file path in git source %s, expected git::<repo-url>//<path>", source)
+	}
+	repo := rest[:offset+i]
+	path := strings.Trim(rest[offset+i+2:], "/")
+	if repo == "" || path == "" {
+		return gitSource{}, fmt.Errorf("invalid git source %s, expected git::<repo-url>//<path>", source)
+	}
+	return gitSource{Repo: repo, Path: path, Ref: ref}, nil
+}
+
+// resolveOpenApiSource returns a local path for the OpenAPI source. Git sources
+// are shallow-cloned into the user cache folder first; other sources are returned
+// as is.
+func resolveOpenApiSource(source string) (string, error) {
+	if !strings.HasPrefix(source, gitSourcePrefix) {
+		return source, nil
+	}
+	src, err := parseGitSource(source)
+	if err != nil {
+		return "", err
+	}
+
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache folder: %w", err)
+	}
+	hash := sha256.Sum256([]byte(src.Repo + "@" + src.Ref))
+	cloneDir := filepath.Join(cacheDir, "openapi-to-mock-server", "git", hex.EncodeToString(hash[:8]))
+
+	if err := fetchGitSource(src, cloneDir); err != nil {
+		return "", err
+	}
+
+	filePath := filepath.Join(cloneDir, filepath.FromSlash(src.Path))
+	if _, err := os.Stat(filePath); err != nil {
+		return "", fmt.Errorf("OpenAPI file %s not found in %s: %w", src.Path, src.Repo, err)
+	}
+	return filePath, nil
+}
+
+// fetchGitSource shallow-clones the repository into the cache folder, or updates
+// the cached clone. A cached clone is used as is when it cannot be updated.
+func fetchGitSource(src gitSource, cloneDir string) error {
+	if _, err := os.Stat(filepath.Join(cloneDir, ".git")); err == nil {
+		ref := src.Ref
+		if ref == "" {
+			ref = "HEAD"
+		}
+		err := runGit(src.Repo, "-C", cloneDir, "fetch", "--depth", "1", "origin", ref)
+		if err == nil {
+			err = runGit(src.Repo, "-C", cloneDir, "checkout", "--force", "FETCH_HEAD")
+		}
+		if err != nil {
+			warnf("failed to update %s, using cached copy: %v", src.Repo, err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(cloneDir), 0755); err != nil {
+		return fmt.Errorf("failed to create cache folder: %w", err)
+	}
+	args := []string{"clone", "--depth", "1"}
+	if src.Ref != "" {
+		args = append(args, "--branch", src.Ref)
+	}
+	args = append(args, src.Repo, cloneDir)
+	if err := runGit(src.Repo, args...); err != nil {
+		os.RemoveAll(cloneDir)
+		return fmt.Errorf("failed to clone %s: %w", src.Repo, err)
+	}
+	return nil
+}
+
+// runGit runs a git command. For https repositories, the token from the
+// MOCK_GIT_TOKEN environment variable is sent as basic authentication without
+// being stored in the clone.
+func runGit(repo string, args ...string) error {
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	if token := os.Getenv(gitTokenEnv); token != "" && strings.HasPrefix(repo, "https://") {
+		credentials := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+		cmd.Env = append(cmd.Env, gitConfigEnv("http.extraHeader", "Authorization: Basic "+credentials)...)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// gitConfigEnv returns the environment variables adding a configuration entry
+// to git, after the ones already given by GIT_CONFIG_COUNT. Unlike -c, they are
+// not shown in the command line of the process.
+func gitConfigEnv(key, value string) []string {
+	count, _ := strconv.Atoi(os.Getenv("GIT_CONFIG_COUNT"))
+	return []string{
+		fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", count, key),
+		fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", count, value),
+		fmt.Sprintf("GIT_CONFIG_COUNT=%d", count+1),
+	}
+}
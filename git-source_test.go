@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/base64"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestParseGitSource(t *testing.T) {
+	src, err := parseGitSource("git::https://github.com/org/repo//path/spec.yaml?ref=main")
+	if err != nil {
+		t.Fatalf("Failed to parse git source: %v", err)
+	}
+	expected := gitSource{Repo: "https://github.com/org/repo", Path: "path/spec.yaml", Ref: "main"}
+	if src != expected {
+		t.Errorf("expect %+v, got %+v", expected, src)
+	}
+
+	if _, err := parseGitSource("git::https://github.com/org/repo"); err == nil {
+		t.Errorf("expect error for git source without file path")
+	}
+}
+
+func TestResolveOpenApiSourceFromGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	// Create a bare repository holding the spec on a "main" branch
+	workDir := t.TempDir()
+	bareDir := filepath.Join(t.TempDir(), "specs.git")
+	if err := os.MkdirAll(filepath.Join(workDir, "api"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	spec := "openapi: \"3.0.0\"\ninfo:\n  title: Git API\n  version: 1.0.0\npaths: {}\n"
+	if err := os.WriteFile(filepath.Join(workDir, "api", "spec.yaml"), []byte(spec), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"-C", workDir, "init", "-q", "-b", "main"},
+		{"-C", workDir, "add", "."},
+		{"-C", workDir, "-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "spec"},
+		{"clone", "-q", "--bare", workDir, bareDir},
+	} {
+		if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, output)
+		}
+	}
+
+	source := "git::file://" + bareDir + "//api/spec.yaml?ref=main"
+	for i := 0; i < 2; i++ { // the second run uses the cached clone
+		filePath, err := resolveOpenApiSource(source)
+		if err != nil {
+			t.Fatalf("Failed to resolve git source: %v", err)
+		}
+		data, err := os.ReadFile(filePath)
+		if err != nil || string(data) != spec {
+			t.Fatalf("expect fetched spec, got %q (%v)", data, err)
+		}
+	}
+
+	if _, err := resolveOpenApiSource("git::file://" + bareDir + "//missing.yaml?ref=main"); err == nil {
+		t.Errorf("expect error for missing file")
+	}
+}
+
+func TestRunGitToken(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake git is a shell script")
+	}
+	// A fake git recording its arguments and configuration
+	binDir := t.TempDir()
+	output := filepath.Join(t.TempDir(), "git.out")
+	script := "#!/bin/sh\necho \"args: $*\" > " + output + "\necho \"key: $GIT_CONFIG_KEY_1\" >> " + output + "\necho \"value: $GIT_CONFIG_VALUE_1\" >> " + output + "\necho \"count: $GIT_CONFIG_COUNT\" >> " + output + "\n"
+	if err := os.WriteFile(filepath.Join(binDir, "git"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv(gitTokenEnv, "secret")
+
+	if err := runGit("https://github.com/org/repo", "fetch", "origin"); err != nil {
+		t.Fatalf("Failed to run git: %v", err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentials := base64.StdEncoding.EncodeToString([]byte("x-access-token:secret"))
+	expected := "args: fetch origin\nkey: http.extraHeader\nvalue: Authorization: Basic " + credentials + "\ncount: 2\n"
+	if string(data) != expected {
+		t.Errorf("expect the token in the environment only, got:\n%s", data)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+)
+
+// indexFileName is the name of the machine-readable route index.
+const indexFileName = "index.json"
+
+// routeIndex is the machine-readable index of the routes of a mock server and
+// of their body files.
+type routeIndex struct {
+	Name  string      `json:"name"`
+	Paths []indexPath `json:"paths"`
+}
+
+// indexPath lists the operations of a path, under the summary and description
+// of the path item. The URL is the full address of the path on the mock server,
+// under the base path of the first server of the spec.
+type indexPath struct {
+	Path        string           `json:"path"`
+	URL         string           `json:"url"`
+	Summary     string           `json:"summary,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Operations  []indexOperation `json:"operations"`
+}
+
+// indexOperation lists the responses of an operation.
+type indexOperation struct {
+	Method    string          `json:"method"`
+	Name      string          `json:"name"`
+	Responses []indexResponse `json:"responses"`
+}
+
+// indexResponse is a response of an operation and the file its body is saved to.
+type indexResponse struct {
+	Code        int    `json:"code"`
+	Name        string `json:"name"`
+	Query       string `json:"query,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	FilePath    string `json:"filePath,omitempty"`
+}
+
+// buildIndex builds the route index of the mock server, sorted by path and method.
+func (m *MockServerSetting) buildIndex() routeIndex {
+	index := routeIndex{Name: m.Name, Paths: []indexPath{}}
+	positions := map[string]int{}
+	for _, request := range m.Requests {
+		operation := indexOperation{Method: request.Method, Name: request.Name, Responses: []indexResponse{}}
+		for _, response := range request.Responses {
+			entry := indexResponse{
+				Code:        response.Code,
+				Name:        response.Name,
+				Query:       response.Query,
+				ContentType: response.ContentType(),
+			}
+			if response.FilePath != nil {
+				entry.FilePath = *response.FilePath
+			}
+			operation.Responses = append(operation.Responses, entry)
+		}
+
+		position, ok := positions[request.Path]
+		if !ok {
+			position = len(index.Paths)
+			positions[request.Path] = position
+			info := m.Paths[request.Path]
+			index.Paths = append(index.Paths, indexPath{Path: request.Path, URL: m.baseURL() + m.basePath() + request.Path, Summary: info.Summary, Description: info.Description})
+		}
+		index.Paths[position].Operations = append(index.Paths[position].Operations, operation)
+	}
+
+	sort.Slice(index.Paths, func(i, j int) bool {
+		return index.Paths[i].Path < index.Paths[j].Path
+	})
+	for _, path := range index.Paths {
+		sort.SliceStable(path.Operations, func(i, j int) bool {
+			return path.Operations[i].Method < path.Operations[j].Method
+		})
+	}
+	return index
+}
+
+// basePath returns the path of the first server of the spec, e.g. /v2 for
+// http://example.com/v2, without trailing slash. It is empty without servers.
+func (m *MockServerSetting) basePath() string {
+	if len(m.Servers) == 0 {
+		return ""
+	}
+	serverURL, err := url.Parse(m.Servers[0])
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(serverURL.Path, "/")
+}
+
+// saveIndexFile writes the route index into index.json.
+func (m *MockServerSetting) saveIndexFile() error {
+	data, err := json.MarshalIndent(m.buildIndex(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal route index: %w", err)
+	}
+	indexFilePath := fmt.Sprintf("%s/%s", m.Folder, indexFileName)
+	if err := os.WriteFile(indexFilePath, data, m.Options.fileMode()); err != nil {
+		return fmt.Errorf("failed to write route index to file: %w", err)
+	}
+	infof("Route index is saved to %s", indexFilePath)
+	return nil
+}
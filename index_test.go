@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveIndexFile(t *testing.T) {
+	setting := generateTestMock(t, `
+openapi: "3.0.0"
+info:
+  title: Pet API
+  version: 1.0.0
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              example: {"name": "Tom"}
+        "404":
+          description: Not Found
+    delete:
+      operationId: deletePet
+      responses:
+        "204":
+          description: No Content
+  /owners:
+    get:
+      operationId: listOwners
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              example: []
+`, Options{Index: true})
+
+	data, err := os.ReadFile(filepath.Join(setting.Folder, indexFileName))
+	if err != nil {
+		t.Fatalf("Failed to read index.json: %v", err)
+	}
+	var index routeIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		t.Fatalf("Invalid index.json: %v", err)
+	}
+
+	if index.Name != "Pet API" || len(index.Paths) != 2 || index.Paths[0].Path != "/owners" || index.Paths[1].Path != "/pets/{id}" {
+		t.Fatalf("expect paths sorted, got:\n%s", data)
+	}
+	operations := index.Paths[1].Operations
+	if len(operations) != 2 || operations[0].Method != "DELETE" || operations[1].Method != "GET" {
+		t.Fatalf("expect operations sorted by method, got:\n%s", data)
+	}
+
+	responses := operations[1].Responses
+	if len(responses) != 2 || responses[0].Code != 200 || responses[1].Code != 404 {
+		t.Fatalf("expect the response codes of getPet, got:\n%s", data)
+	}
+	if responses[0].ContentType != "application/json" || responses[1].FilePath != "" {
+		t.Errorf("unexpected responses of getPet:\n%s", data)
+	}
+
+	// The file paths point to the generated body files
+	bodyPath := strings.TrimPrefix(responses[0].FilePath, "./data/Pet_API/")
+	if body, err := os.ReadFile(filepath.Join(setting.Folder, bodyPath)); err != nil || !strings.Contains(string(body), "Tom") {
+		t.Errorf("expect %s to be the body file (%v)", responses[0].FilePath, err)
+	}
+}
+
+func TestIndexPathDescription(t *testing.T) {
+	setting := generateTestMock(t, `
+openapi: "3.0.0"
+info:
+  title: Pet API
+  version: 1.0.0
+paths:
+  /pets:
+    summary: Pets
+    description: The pets of the store.
+    get:
+      operationId: listPets
+      summary: List the pets
+      responses:
+        '200':
+          description: OK
+  /owners:
+    get:
+      operationId: listOwners
+      responses:
+        '200':
+          description: OK
+`, Options{Index: true})
+
+	data, err := os.ReadFile(filepath.Join(setting.Folder, indexFileName))
+	if err != nil {
+		t.Fatalf("Failed to read index.json: %v", err)
+	}
+	var index routeIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		t.Fatalf("Invalid index.json: %v", err)
+	}
+	if len(index.Paths) != 2 {
+		t.Fatalf("expect two paths, got:\n%s", data)
+	}
+	if pets := index.Paths[1]; pets.Summary != "Pets" || pets.Description != "The pets of the store." {
+		t.Errorf("expect the path item summary and description, got:\n%s", data)
+	}
+	if strings.Contains(string(data), "List the pets") {
+		t.Errorf("expect operation summaries not to be used as path descriptions, got:\n%s", data)
+	}
+	if owners := index.Paths[0]; owners.Summary != "" || owners.Description != "" {
+		t.Errorf("expect no description for /owners, got:\n%s", data)
+	}
+}
+
+func TestIndexPathURL(t *testing.T) {
+	setting := generateTestMock(t, `
+openapi: "3.0.0"
+info:
+  title: User API
+  version: 1.0.0
+paths:
+  /v2/users:
+    get:
+      operationId: listUsers
+      responses:
+        '200':
+          description: OK
+`, Options{Index: true, Host: "127.0.0.1", Port: 12345})
+
+	index := setting.buildIndex()
+	if url := index.Paths[0].URL; url != "http://127.0.0.1:12345/v2/users" {
+		t.Errorf("expect the full URL of the path, got %s", url)
+	}
+}
+
+func TestIndexPathURLServerBasePath(t *testing.T) {
+	setting := generateTestMock(t, `
+openapi: "3.0.0"
+info:
+  title: User API
+  version: 1.0.0
+servers:
+  - url: http://api.example.com/v2/
+paths:
+  /users:
+    get:
+      operationId: listUsers
+      responses:
+        '200':
+          description: OK
+`, Options{Index: true, Host: "127.0.0.1", Port: 12345})
+
+	index := setting.buildIndex()
+	if url := index.Paths[0].URL; url != "http://127.0.0.1:12345/v2/users" {
+		t.Errorf("expect the base path of the server in the URL, got %s", url)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"sort"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Link is a relationship between a response and an operation, from the links
+// of the response in the spec, so that tooling can follow it.
+type Link struct {
+	Name         string                 `yaml:"name" json:"name"`
+	OperationID  string                 `yaml:"operationId,omitempty" json:"operationId,omitempty"`
+	OperationRef string                 `yaml:"operationRef,omitempty" json:"operationRef,omitempty"`
+	Description  string                 `yaml:"description,omitempty" json:"description,omitempty"`
+	Parameters   map[string]interface{} `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+	RequestBody  interface{}            `yaml:"requestBody,omitempty" json:"requestBody,omitempty"`
+}
+
+// responseLinks returns the links of a response of the spec, sorted by name.
+func responseLinks(response *openapi3.Response) []Link {
+	names := make([]string, 0, len(response.Links))
+	for name := range response.Links {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	links := []Link{}
+	for _, name := range names {
+		link := response.Links[name]
+		if link == nil || link.Value == nil {
+			continue
+		}
+		links = append(links, Link{
+			Name:         name,
+			OperationID:  link.Value.OperationID,
+			OperationRef: link.Value.OperationRef,
+			Description:  link.Value.Description,
+			Parameters:   link.Value.Parameters,
+			RequestBody:  link.Value.RequestBody,
+		})
+	}
+	if len(links) == 0 {
+		return nil
+	}
+	return links
+}
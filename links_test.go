@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestExtractResponseLinks(t *testing.T) {
+	setting := generateTestMock(t, `
+openapi: "3.0.0"
+info:
+  title: User API
+  version: 1.0.0
+paths:
+  /users:
+    post:
+      operationId: createUser
+      responses:
+        '201':
+          description: Created
+          content:
+            application/json:
+              example: {"id": 1}
+          links:
+            GetUserById:
+              operationId: getUser
+              description: The created user
+              parameters:
+                id: $response.body#/id
+  /users/{id}:
+    get:
+      operationId: getUser
+      responses:
+        '200':
+          description: OK
+`, Options{})
+
+	for _, request := range setting.Requests {
+		if request.Name != "createUser" {
+			continue
+		}
+		links := request.Responses[0].Links
+		if len(links) != 1 || links[0].Name != "GetUserById" || links[0].OperationID != "getUser" || links[0].Parameters["id"] != "$response.body#/id" {
+			t.Errorf("unexpected links %+v", links)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(setting.Folder, "setting.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read setting: %v", err)
+	}
+	if !strings.Contains(string(data), "operationId: getUser") {
+		t.Errorf("expect the links in the setting, got %s", data)
+	}
+}
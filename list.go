@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// runList parses the OpenAPI file given in the arguments and prints its routes
+// as a table of method, path, operation id and response codes, without writing
+// anything.
+func runList(args []string, out io.Writer) error {
+	if len(args) != 1 {
+		return withExitCode(ExitUsage, fmt.Errorf("expected one OpenAPI file, got %d arguments", len(args)))
+	}
+	openApiFile, err := resolveOpenApiSource(args[0])
+	if err != nil {
+		return withExitCode(ExitSpecNotFound, fmt.Errorf("failed to fetch OpenAPI file: %w", err))
+	}
+	openAPISpec, err := ParseOpenApiFile(openApiFile)
+	if err != nil {
+		return err
+	}
+
+	// Only list the routes, without the progress of the conversion
+	defer func(level int) { logLevel = level }(logLevel)
+	logLevel = levelWarn
+
+	opts := Options{Extensions: map[string]string{}, ContentTypes: map[string]string{}, DefaultBodies: map[string]string{}, SelectorParams: map[string]string{}}
+	requests := getRequests(openAPISpec, getSchemaExamples(openAPISpec, opts), opts)
+	sort.SliceStable(requests, func(i, j int) bool {
+		if requests[i].Path != requests[j].Path {
+			return requests[i].Path < requests[j].Path
+		}
+		return requests[i].Method < requests[j].Method
+	})
+
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "METHOD\tPATH\tOPERATION\tCODES")
+	for _, request := range requests {
+		codes := []string{}
+		for _, response := range request.Responses {
+			code := strconv.Itoa(response.Code)
+			if len(codes) == 0 || codes[len(codes)-1] != code {
+				codes = append(codes, code)
+			}
+		}
+		operationID := ""
+		if request.Operation != nil {
+			operationID = request.Operation.OperationID
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", request.Method, request.Path, operationID, strings.Join(codes, ","))
+	}
+	return writer.Flush()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const listSpec = `
+openapi: "3.0.0"
+info:
+  title: List API
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              example: []
+    post:
+      operationId: createPet
+      responses:
+        "201":
+          description: Created
+        "400":
+          description: Bad Request
+  /owners/{id}:
+    get:
+      responses:
+        "404":
+          description: Not Found
+        "200":
+          description: OK
+`
+
+func TestRunList(t *testing.T) {
+	folder := t.TempDir()
+	specFile := filepath.Join(folder, "openapi.yaml")
+	if err := os.WriteFile(specFile, []byte(listSpec), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := runList([]string{specFile}, &out); err != nil {
+		t.Fatalf("Failed to list routes: %v", err)
+	}
+
+	var rows []string
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		rows = append(rows, strings.Join(strings.Fields(line), " "))
+	}
+	expected := []string{
+		"METHOD PATH OPERATION CODES",
+		"GET /owners/{id} 200,404",
+		"GET /pets listPets 200",
+		"POST /pets createPet 201,400",
+	}
+	if strings.Join(rows, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected routes:\n%s\ngot:\n%s", strings.Join(expected, "\n"), strings.Join(rows, "\n"))
+	}
+
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		t.Fatalf("Failed to read folder: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected nothing written by list, got %d entries", len(entries))
+	}
+}
+
+func TestRunListUsage(t *testing.T) {
+	if code := exitCode(runList(nil, &bytes.Buffer{})); code != ExitUsage {
+		t.Errorf("Expected exit code %d without a spec, got %d", ExitUsage, code)
+	}
+}
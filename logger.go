@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Log levels, from the most to the least verbose.
+const (
+	levelDebug = iota
+	levelInfo
+	levelWarn
+)
+
+// logLevel is the minimum level of the messages written to the log.
+var logLevel = levelInfo
+
+// parseLogLevel parses the name of a log level: debug, info or warn.
+func parseLogLevel(name string) (int, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return levelDebug, nil
+	case "info":
+		return levelInfo, nil
+	case "warn", "warning":
+		return levelWarn, nil
+	}
+	return 0, fmt.Errorf("invalid log level %q, expected debug, info or warn", name)
+}
+
+// debugf logs a diagnostic message.
+func debugf(format string, v ...interface{}) {
+	if logLevel <= levelDebug {
+		log.Printf(format, v...)
+	}
+}
+
+// infof logs a progress message.
+func infof(format string, v ...interface{}) {
+	if logLevel <= levelInfo {
+		log.Printf(format, v...)
+	}
+}
+
+// warnf logs a warning.
+func warnf(format string, v ...interface{}) {
+	if logLevel <= levelWarn {
+		log.Printf("Warning: "+format, v...)
+	}
+}
+
+// bodyPreviewLength is the number of characters of a body shown in its preview.
+const bodyPreviewLength = 200
+
+// bodyPreview returns the beginning of a body on a single line, truncated with an
+// ellipsis to the given number of characters, followed by the length of the body.
+func bodyPreview(body string, length int) string {
+	preview := []rune(strings.Join(strings.Fields(body), " "))
+	if len(preview) > length {
+		return fmt.Sprintf("%s… (%d bytes)", string(preview[:length]), len(body))
+	}
+	return fmt.Sprintf("%s (%d bytes)", string(preview), len(body))
+}
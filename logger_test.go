@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestBodyPreview(t *testing.T) {
+	body := strings.Repeat("a", 300)
+	if preview := bodyPreview(body, 200); preview != strings.Repeat("a", 200)+"… (300 bytes)" {
+		t.Errorf("expect truncated preview, got %q", preview)
+	}
+	if preview := bodyPreview("{\n  \"name\": \"Tom\"\n}", 200); preview != `{ "name": "Tom" } (19 bytes)` {
+		t.Errorf("expect single line preview, got %q", preview)
+	}
+}
+
+func TestDebugLogLevel(t *testing.T) {
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+	defer func(level int) { logLevel = level }(logLevel)
+
+	debugf("hidden")
+	if output.Len() != 0 {
+		t.Errorf("expect debug messages to be hidden by default, got %q", output.String())
+	}
+
+	level, err := parseLogLevel("debug")
+	if err != nil {
+		t.Fatalf("Failed to parse log level: %v", err)
+	}
+	logLevel = level
+	debugf("shown")
+	if !strings.Contains(output.String(), "shown") {
+		t.Errorf("expect debug messages at debug level, got %q", output.String())
+	}
+
+	if _, err := parseLogLevel("verbose"); err == nil {
+		t.Errorf("expect error for unknown log level")
+	}
+}
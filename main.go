@@ -1,47 +1,364 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
 )
 
 func main() {
-	// read the command line arguments for openapi file and data folder
-	if len(os.Args) != 3 {
-		log.Fatalf("Usage: %s <openapi-file> <target-folder>", os.Args[0])
+	os.Exit(run(os.Args[1:]))
+}
+
+// run runs the command with its arguments and returns the exit code, one of
+// the Exit constants.
+func run(args []string) int {
+	// run the conversion as an HTTP service
+	if len(args) > 0 && args[0] == "serve" {
+		if err := runServe(args[1:]); err != nil {
+			log.Printf("Conversion service failed: %v", err)
+			return ExitFailure
+		}
+		return ExitOK
+	}
+
+	// print the routes of the spec without generating anything
+	if len(args) > 0 && args[0] == "list" {
+		if err := runList(args[1:], os.Stdout); err != nil {
+			log.Printf("%v", err)
+			return exitCode(err)
+		}
+		return ExitOK
+	}
+
+	// read the command line options
+	opts := Options{Extensions: map[string]string{}, ContentTypes: map[string]string{}, DefaultBodies: map[string]string{}, SelectorParams: map[string]string{}}
+	defaultPort, err := envPortValue()
+	if err != nil {
+		log.Printf("%v", err)
+		return ExitUsage
+	}
+	flags := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	flags.StringVar(&opts.Host, "host", os.Getenv(envHost), "host of the mock server, defaults to $"+envHost+" or the first server of the spec")
+	opts.Port = defaultPort
+	flags.Func("port", "fixed port of the mock server, from 1 to 65535, defaults to $"+envPort+", the first server of the spec or a random port", func(value string) (err error) {
+		opts.Port, err = parsePort(value)
+		return err
+	})
+	flags.Func("base-port", "first port of the mock server, incremented past the ports of the other mock servers of the target folder", func(value string) (err error) {
+		opts.BasePort, err = parsePort(value)
+		return err
+	})
+	var openApi string
+	flags.StringVar(&openApi, "openapi", "", "OpenAPI file, instead of the argument, e.g. git::https://github.com/org/repo//path/spec.yaml?ref=main")
+	var targets stringListFlag
+	flags.Var(&targets, "target", "target folder to write the mock server to, in addition to the argument (repeatable)")
+	flags.BoolVar(&opts.SingleFile, "single-file", false, "write all response bodies into a single bodies.json")
+	flags.BoolVar(&opts.PlainNames, "plain-names", false, "strip the Markdown formatting of the descriptions the response names are derived from")
+	flags.Func("name-pattern", "regular expression extracting the name of the responses from their description, e.g. '^\\[(\\w+)\\]'", func(value string) (err error) {
+		opts.NamePattern, err = parseNamePattern(value)
+		return err
+	})
+	flags.StringVar(&opts.NameReplacement, "name-replacement", "", "name expanded from the match of --name-pattern, e.g. $1 (default the first capture group or the whole match)")
+	flags.Func("rewrite", "rewrite the paths starting with a pattern, keeping its parameters, e.g. '/old/{id}=>/new/{id}' (repeatable)", func(value string) error {
+		rewrite, err := parsePathRewrite(value)
+		if err != nil {
+			return err
+		}
+		opts.Rewrites = append(opts.Rewrites, rewrite)
+		return nil
+	})
+	flags.BoolVar(&opts.TrimCommonPrefix, "trim-common-prefix", false, "strip the longest path prefix shared by all operations, e.g. /api/v1, from the paths")
+	flags.BoolVar(&opts.Flatten, "flatten", false, "write all body files into the mock server folder, named <method>_<name>_<code>")
+	flags.BoolVar(&opts.TrailingNewline, "trailing-newline", false, "end each body file with exactly one newline")
+	flags.StringVar(&opts.PostHook, "post-hook", "", "shell command to run after the generation completes")
+	flags.BoolVar(&opts.PreferExample, "prefer-example", false, "prefer the singular example over named examples")
+	flags.BoolVar(&opts.PreferSchema, "prefer-schema", false, "prefer generating bodies from the schema over named examples")
+	flags.StringVar(&opts.Environment, "env", "", "select the example values of this environment from their x-mock-env extension, e.g. staging")
+	flags.BoolVar(&opts.ReparseStringExamples, "reparse-string-examples", false, "parse examples holding stringified JSON or YAML into structured bodies")
+	flags.Func("enum-strategy", "value picked from enums: first (default), last, random or index:N", func(value string) (err error) {
+		opts.EnumStrategy, err = parseEnumStrategy(value)
+		return err
+	})
+	flags.Func("seed", "seed of the random values, for reproducible examples", func(value string) error {
+		seed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid seed %q", value)
+		}
+		opts.Seed = &seed
+		return nil
+	})
+	flags.StringVar(&opts.ExampleName, "example-name", "", "only generate the named example with this name when a response has it")
+	flags.BoolVar(&opts.EmitHealth, "emit-health", false, "add a health-check route if the spec does not define one")
+	flags.StringVar(&opts.HealthPath, "health-path", "/healthz", "path of the health-check route")
+	flags.StringVar(&opts.Operation, "operation", "", "only generate the operation with this operationId")
+	flags.BoolVar(&opts.ExcludeDeprecated, "exclude-deprecated", false, "skip operations marked as deprecated")
+	flags.BoolVar(&opts.Quiet, "quiet", false, "only log warnings and errors")
+	flags.BoolVar(&opts.StrictExamples, "strict-examples", false, "fail when a response example violates its schema")
+	flags.BoolVar(&opts.Strict, "strict", false, "fail on spec issues like unused component schemas")
+	flags.Func("log-level", "minimum level of the logged messages: debug, info (default) or warn", func(value string) (err error) {
+		logLevel, err = parseLogLevel(value)
+		return err
+	})
+	flags.Var(keyValueFlag(opts.Extensions), "ext", "file extension for a content type, e.g. text/html=.htm (repeatable)")
+	flags.Var(keyValueFlag(opts.DefaultBodies), "default-body", "body of the success responses without any, by content type, e.g. application/json={} or *=OK (repeatable)")
+	flags.Var(keyValueFlag(opts.ContentTypes), "content-type", "Content-Type of the responses of a route, e.g. 'GET /legacy=text/xml' (repeatable)")
+	flags.Func("default-code", "status code of the default responses (default 200 without a success response, else 500)", func(value string) error {
+		codes, err := parseCodes(value)
+		if err != nil || len(codes) != 1 {
+			return fmt.Errorf("invalid default code %q", value)
+		}
+		opts.DefaultCode = codes[0]
+		return nil
+	})
+	flags.Func("codes", "comma separated response codes to generate, e.g. 200,201,204", func(value string) (err error) {
+		opts.Codes, err = parseCodes(value)
+		return err
+	})
+	flags.BoolVar(&opts.SchemasOnly, "schemas-only", false, "only write the component schema examples into <target-folder>/schemas.json")
+	flags.BoolVar(&opts.EmitErrors, "emit-errors", false, "write the distinct error responses into errors.json")
+	flags.BoolVar(&opts.Report, "report", false, "write the reference count of each component schema into refs.json")
+	flags.BoolVar(&opts.EmitSchemas, "emit-schemas", false, "write the example of each component schema into schemas.json")
+	flags.StringVar(&opts.Description, "description", "", "description of the mock server, instead of the description of the spec")
+	flags.BoolVar(&opts.NoDescription, "no-description", false, "omit the description of the mock server from the setting")
+	flags.Func("data-dir", "folder of the target folder the mock servers are written into (default data)", func(value string) (err error) {
+		opts.DataDir, err = parseDataDir(value)
+		return err
+	})
+	flags.BoolVar(&opts.NoDataDir, "no-data-dir", false, "write the mock servers directly into the target folder")
+	flags.Func("folder-template", "name of the mock server folder with {name}, {version} and {date} tokens, e.g. {name}-v{version}-{date}", func(value string) (err error) {
+		opts.FolderTemplate, err = parseFolderTemplate(value)
+		return err
+	})
+	flags.StringVar(&opts.SettingName, "setting-name", "setting.yaml", "file name of the mock server setting, JSON if it ends with .json")
+	flags.Func("selector-param", "rename a selector param of the query strings: <name> for key, or key|contentType|name=<name> (repeatable)", func(value string) error {
+		return parseSelectorParam(value, opts.SelectorParams)
+	})
+	flags.Func("yaml-indent", "number of spaces the YAML setting is indented with (default 2)", func(value string) (err error) {
+		opts.YAMLIndent, err = parseYAMLIndent(value)
+		return err
+	})
+	flags.BoolVar(&opts.YAMLAnchors, "yaml-anchors", false, "deduplicate repeated header lists of the YAML setting with anchors")
+	flags.Func("path-style", "style of the recorded file paths: target (default), relative or absolute", func(value string) (err error) {
+		opts.PathStyle, err = parsePathStyle(value)
+		return err
+	})
+	flags.BoolVar(&opts.EmitCallbacks, "emit-callbacks", false, "add a request for each callback of the operations")
+	flags.BoolVar(&opts.EmitFragments, "emit-fragments", false, "write an OpenAPI fragment of the operation into each request folder")
+	flags.BoolVar(&opts.EmitRequestExamples, "emit-request-examples", false, "write the request body examples into a request folder under each request folder")
+	flags.Func("pad", "size in bytes the response bodies are padded up to, for performance testing", func(value string) error {
+		pad, err := strconv.Atoi(value)
+		if err != nil || pad < 0 {
+			return fmt.Errorf("invalid pad %q, expected a number of bytes", value)
+		}
+		opts.Pad = pad
+		return nil
+	})
+	flags.StringVar(&opts.Diff, "diff", "", "write the changes since the previous generation into this file, - for the standard output")
+	flags.BoolVar(&opts.Merge, "merge", false, "merge into the existing setting file, keeping the headers, queries and other fields edited by hand")
+	flags.BoolVar(&opts.Prune, "prune", false, "remove the requests no longer in the spec when merging")
+	flags.BoolVar(&opts.NoCopySpec, "no-copy-spec", false, "do not copy the OpenAPI file into the mock server folder")
+	flags.BoolVar(&opts.ExternalRefs, "external-refs", false, "resolve the references to other files or URLs, including a root document that is only a $ref")
+	flags.Func("file-mode", "octal permissions of the generated files (default 0644)", func(value string) (err error) {
+		opts.FileMode, err = parseFileMode(value)
+		return err
+	})
+	flags.Func("dir-mode", "octal permissions of the generated folders (default 0755)", func(value string) (err error) {
+		opts.DirMode, err = parseFileMode(value)
+		return err
+	})
+	flags.StringVar(&opts.Zip, "zip", "", "package the generated mock server folder into this zip archive")
+	flags.BoolVar(&opts.EmitCacheHeaders, "emit-cache-headers", false, "add ETag and Last-Modified headers to the responses with a body")
+	flags.BoolVar(&opts.PortsLock, "ports-lock", false, "record the host and port of the mock server in ports.lock of the target folder")
+	flags.BoolVar(&opts.Index, "index", false, "write index.json listing the routes and their body files")
+	flags.Func("export", "write a script calling the routes: k6 (loadtest.js)", func(value string) (err error) {
+		opts.Export, err = parseExport(value)
+		return err
+	})
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitUsage
+	}
+	if opts.Quiet {
+		logLevel = levelWarn
+	}
+
+	// read the command line arguments for openapi file and data folder,
+	// falling back to the environment
+	openApiFile, targetFolders, err := commandArgs(flags.Args(), openApi, targets)
+	if err != nil {
+		log.Printf("Usage: %s [--port <port>] [options] <openapi-file> [<target-folder>]\n       %s [options] --openapi <openapi-file> [<target-folder>]\n       %s list <openapi-file>\n       %s serve [--listen <address>]", os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+		return ExitUsage
+	}
+
+	// generate only the part of the spec a JSON Pointer refers to, if any
+	openApiFile, opts.SpecPointer = splitSpecPointer(openApiFile)
+
+	// fetch the openapi file if it is stored in a git repository
+	openApiFile, err = resolveOpenApiSource(openApiFile)
+	if err != nil {
+		log.Printf("Failed to fetch OpenAPI file: %v", err)
+		return ExitSpecNotFound
 	}
-	openApiFile := os.Args[1]
-	targetFolder := os.Args[2]
 
 	// validate the openapi file existence
 	if _, err := os.Stat(openApiFile); os.IsNotExist(err) {
-		log.Fatalf("OpenAPI file does not exist: %s", openApiFile)
+		log.Printf("OpenAPI file does not exist: %s", openApiFile)
+		return ExitSpecNotFound
 	}
 
-	// verify if traget folder does not exist
-	if _, err := os.Stat(targetFolder); os.IsNotExist(err) {
-		log.Fatalf("Failed to create data folder: %v", err)
+	// verify if traget folders do not exist
+	for _, targetFolder := range targetFolders {
+		if _, err := os.Stat(targetFolder); os.IsNotExist(err) {
+			log.Printf("Failed to create data folder: %v", err)
+			return ExitWrite
+		}
 	}
 
-	log.Printf("Exporting OpenAPI to mock server: %s -> %s\n", openApiFile, targetFolder)
+	infof("Exporting OpenAPI to mock server: %s -> %s", openApiFile, strings.Join(targetFolders, ", "))
 
 	// export OpenAPI to mock server
-	exportOpenAPIToMockServer(openApiFile, targetFolder)
+	if err := exportOpenAPIToMockServer(openApiFile, targetFolders, opts); err != nil {
+		log.Printf("%v", err)
+		return exitCode(err)
+	}
+	return ExitOK
 }
 
-func exportOpenAPIToMockServer(openApiFile string, targetFolder string) {
-	// Step 1: Read the OpenAPI file.
-	openAPISpec := ParseOpenApiFile(openApiFile)
+// exportOpenAPIToMockServer converts the OpenAPI file once and writes the mock
+// server into each target folder. The errors carry the exit code of their
+// category.
+func exportOpenAPIToMockServer(openApiFile string, targetFolders []string, opts Options) error {
+	// Only log the warnings and errors of a quiet generation
+	if opts.Quiet && logLevel < levelWarn {
+		defer func(level int) { logLevel = level }(logLevel)
+		logLevel = levelWarn
+	}
+
+	// Step 1: Read and check the OpenAPI file.
+	openAPISpec, opts, err := loadCheckedOpenApiFile(openApiFile, opts)
+	if err != nil {
+		return err
+	}
+
+	// Only dump the schema examples in schemas-only mode
+	if opts.SchemasOnly {
+		for _, targetFolder := range targetFolders {
+			if err := SaveSchemaExamples(openAPISpec, targetFolder, opts); err != nil {
+				return withExitCode(ExitWrite, fmt.Errorf("failed to save schema examples: %w", err))
+			}
+		}
+		return nil
+	}
 
 	// Step 2: Convert OpenAPI to mock server.
-	mockServerInfo := ConvertOpenAPIToMockServer(openAPISpec)
+	mockServerInfo := ConvertOpenAPIToMockServer(openAPISpec, opts)
+
+	for _, targetFolder := range targetFolders {
+		// Step 3: Create mock server data folder.
+		if err := mockServerInfo.CreateFolder(targetFolder); err != nil {
+			return withExitCode(ExitWrite, fmt.Errorf("failed to create mock server folder: %w", err))
+		}
 
-	// Step 3: Create mock server data folder.
-	mockServerInfo.CreateFolder(targetFolder)
+		// Step 4: Output mock server setting file
+		if err := mockServerInfo.SaveSetting(); err != nil {
+			return withExitCode(ExitWrite, fmt.Errorf("failed to save mock server: %w", err))
+		}
+		infof("%s", mockServerInfo.Summary())
 
-	// Step 4: Output mock server setting file
-	mockServerInfo.SaveSetting()
+		// record the host and port of the mock server for the supervisors
+		if opts.PortsLock {
+			if err := mockServerInfo.savePortsLock(time.Now()); err != nil {
+				return withExitCode(ExitWrite, err)
+			}
+		}
 
-	// step 5: copy the openapi file to the data folder
-	mockServerInfo.CopyOpenAPIFile(openApiFile)
+		// step 5: copy the openapi file to the data folder
+		if !opts.NoCopySpec {
+			if err := mockServerInfo.CopyOpenAPIFile(openApiFile); err != nil {
+				return withExitCode(ExitWrite, fmt.Errorf("failed to copy OpenAPI file: %w", err))
+			}
+		}
+
+		// step 6: run the post-generation hook
+		if opts.PostHook != "" {
+			if err := mockServerInfo.RunPostHook(opts.PostHook); err != nil {
+				return fmt.Errorf("post-generation hook failed: %w", err)
+			}
+		}
+	}
+
+	// step 7: package the mock server folder, the same in every target
+	if opts.Zip != "" {
+		if err := mockServerInfo.SaveZip(opts.Zip); err != nil {
+			return withExitCode(ExitWrite, fmt.Errorf("failed to archive mock server: %w", err))
+		}
+	}
+	return nil
+}
+
+// loadCheckedOpenApiFile reads the OpenAPI file and runs the checks the options
+// ask for. It returns the options completed with the folder and modification
+// time of the file.
+func loadCheckedOpenApiFile(openApiFile string, opts Options) (openapi3.T, Options, error) {
+	if opts.sandbox {
+		opts.ExternalRefs = false
+	}
+	openAPISpec, err := parseOpenApiFile(openApiFile, opts.ExternalRefs)
+	if err != nil {
+		return openapi3.T{}, opts, err
+	}
+
+	// Keep only the part of the spec the pointer refers to
+	if opts.SpecPointer != "" {
+		if err := selectSpecPointer(&openAPISpec, opts.SpecPointer); err != nil {
+			return openapi3.T{}, opts, withExitCode(ExitValidation, err)
+		}
+	}
+
+	// Report the component schemas no path refers to, the other parts of the
+	// spec use them when only a part is generated
+	if unused := unusedSchemas(openAPISpec); len(unused) > 0 && opts.SpecPointer == "" {
+		infof("Unused component schemas: %s", strings.Join(unused, ", "))
+		if opts.Strict {
+			return openapi3.T{}, opts, withExitCode(ExitValidation, fmt.Errorf("unused component schemas are not allowed in strict mode: %s", strings.Join(unused, ", ")))
+		}
+	}
+
+	// Check the examples match their schemas
+	if opts.StrictExamples {
+		if violations := exampleViolations(openAPISpec); len(violations) > 0 {
+			return openapi3.T{}, opts, withExitCode(ExitValidation, fmt.Errorf("examples violate their schemas:\n  %s", strings.Join(violations, "\n  ")))
+		}
+	}
+
+	// Check the CSV files of the responses exist, a sandboxed conversion reads
+	// no other file than the OpenAPI one
+	if !opts.sandbox {
+		opts.specDir = filepath.Dir(openApiFile)
+	}
+	if info, err := os.Stat(openApiFile); err == nil {
+		opts.specModTime = info.ModTime()
+	}
+	if missing := missingCSVFiles(openAPISpec, opts.specDir); len(missing) > 0 {
+		return openapi3.T{}, opts, withExitCode(ExitValidation, fmt.Errorf("CSV files of the responses are missing:\n  %s", strings.Join(missing, "\n  ")))
+	}
+
+	// Check the operation to generate exists
+	if opts.Operation != "" {
+		if err := checkOperation(openAPISpec, opts.Operation); err != nil {
+			return openapi3.T{}, opts, withExitCode(ExitUsage, err)
+		}
+	}
+	return openAPISpec, opts, nil
 }
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// readTree returns the content of the files under a folder by relative path.
+func readTree(t *testing.T, root string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		relativePath, _ := filepath.Rel(root, path)
+		files[relativePath] = string(data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", root, err)
+	}
+	return files
+}
+
+func TestExportToMultipleTargets(t *testing.T) {
+	openApiFile := filepath.Join(t.TempDir(), "pets.yaml")
+	if err := os.WriteFile(openApiFile, []byte(petSpec), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	local, artifacts := t.TempDir(), t.TempDir()
+
+	if err := exportOpenAPIToMockServer(openApiFile, []string{local, artifacts}, Options{}); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	localFiles, artifactFiles := readTree(t, local), readTree(t, artifacts)
+	if _, ok := localFiles[filepath.Join("data", "Pet_API", "setting.yaml")]; !ok {
+		t.Fatalf("expect setting.yaml in the first target, got %v", localFiles)
+	}
+	if _, ok := localFiles[filepath.Join("data", "Pet_API", "openapi.yaml")]; !ok {
+		t.Errorf("expect the OpenAPI file to be copied, got %v", localFiles)
+	}
+	if len(localFiles) != len(artifactFiles) {
+		t.Fatalf("expect the same files in both targets, got %v and %v", localFiles, artifactFiles)
+	}
+	for path, content := range localFiles {
+		if artifactFiles[path] != content {
+			t.Errorf("expect %s to match in both targets", path)
+		}
+	}
+}
+
+func TestExportSchemasOnly(t *testing.T) {
+	openApiFile := filepath.Join(t.TempDir(), "users.yaml")
+	if err := os.WriteFile(openApiFile, []byte(`
+openapi: "3.0.0"
+info:
+  title: User API
+  version: 1.0.0
+paths:
+  /users:
+    get:
+      operationId: listUsers
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/User"
+components:
+  schemas:
+    User:
+      type: object
+      properties:
+        name:
+          type: string
+          example: Jane
+    Group:
+      type: object
+      properties:
+        id:
+          type: integer
+          example: 7
+`), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	target := t.TempDir()
+
+	if err := exportOpenAPIToMockServer(openApiFile, []string{target}, Options{SchemasOnly: true}); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	files := readTree(t, target)
+	if len(files) != 1 {
+		t.Fatalf("expect only schemas.json, got %v", files)
+	}
+	var schemas map[string]map[string]interface{}
+	if err := json.Unmarshal([]byte(files["schemas.json"]), &schemas); err != nil {
+		t.Fatalf("Invalid schemas.json: %v", err)
+	}
+	if len(schemas) != 2 || schemas["User"]["name"] != "Jane" || schemas["Group"]["id"] != 7.0 {
+		t.Errorf("unexpected schemas.json content: %s", files["schemas.json"])
+	}
+
+	// An example that is not valid JSON is written as a string, not as null
+	for _, example := range []string{"{not json", ""} {
+		if value, ok := schemaExampleValue(example).(string); !ok || value != example {
+			t.Errorf("expect the invalid example %q as a string, got %v", example, schemaExampleValue(example))
+		}
+	}
+}
+
+func TestExportZip(t *testing.T) {
+	openApiFile := filepath.Join(t.TempDir(), "pets.yaml")
+	if err := os.WriteFile(openApiFile, []byte(petSpec), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	target := t.TempDir()
+	zipFile := filepath.Join(t.TempDir(), "mock.zip")
+
+	if err := exportOpenAPIToMockServer(openApiFile, []string{target}, Options{Zip: zipFile}); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	archive, err := zip.OpenReader(zipFile)
+	if err != nil {
+		t.Fatalf("Failed to open zip archive: %v", err)
+	}
+	defer archive.Close()
+	archived := map[string]string{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", file.Name, err)
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file.Name, err)
+		}
+		archived[filepath.FromSlash(file.Name)] = string(data)
+	}
+
+	files := readTree(t, filepath.Join(target, "data", "Pet_API"))
+	if len(files) == 0 || len(archived) != len(files) {
+		t.Fatalf("expect the archive to hold the folder files, got %v and %v", archived, files)
+	}
+	for path, content := range files {
+		if archived[path] != content {
+			t.Errorf("expect %s to match in the archive", path)
+		}
+	}
+}
+
+func TestExportNoCopySpec(t *testing.T) {
+	openApiFile := filepath.Join(t.TempDir(), "pets.yaml")
+	if err := os.WriteFile(openApiFile, []byte(petSpec), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	target := t.TempDir()
+
+	if err := exportOpenAPIToMockServer(openApiFile, []string{target}, Options{NoCopySpec: true}); err != nil {
+		t.Fatalf("Failed to export: %v", err)
+	}
+
+	folder := filepath.Join(target, "data", "Pet_API")
+	if _, err := os.Stat(filepath.Join(folder, "setting.yaml")); err != nil {
+		t.Fatalf("expect the mock server to be generated: %v", err)
+	}
+	if copies, _ := filepath.Glob(filepath.Join(folder, "openapi.*")); len(copies) != 0 {
+		t.Errorf("expect no copy of the OpenAPI file, got %v", copies)
+	}
+}
+
+func TestRunExitCodes(t *testing.T) {
+	defer func(level int) { logLevel = level }(logLevel)
+	folder := t.TempDir()
+	invalidSpec := filepath.Join(folder, "invalid.yaml")
+	if err := os.WriteFile(invalidSpec, []byte("openapi: 3.0.0\npaths: [\n"), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	swaggerSpec := filepath.Join(folder, "swagger.yaml")
+	if err := os.WriteFile(swaggerSpec, []byte("swagger: \"2.0\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	validSpec := filepath.Join(folder, "pets.yaml")
+	if err := os.WriteFile(validSpec, []byte(petSpec), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+
+	cases := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"missing file", []string{filepath.Join(folder, "missing.yaml"), t.TempDir()}, ExitSpecNotFound},
+		{"parse error", []string{invalidSpec, t.TempDir()}, ExitParse},
+		{"unsupported version", []string{swaggerSpec, t.TempDir()}, ExitValidation},
+		{"invalid flag", []string{"--yaml-indent=1", validSpec, t.TempDir()}, ExitUsage},
+		{"port out of range", []string{"--port=70000", validSpec, t.TempDir()}, ExitUsage},
+		{"port zero", []string{"--port=0", validSpec, t.TempDir()}, ExitUsage},
+		{"success", []string{"--quiet", validSpec, t.TempDir()}, ExitOK},
+	}
+	for _, c := range cases {
+		if code := run(c.args); code != c.code {
+			t.Errorf("%s: expect exit code %d, got %d", c.name, c.code, code)
+		}
+	}
+}
+
+func TestRunFixedPort(t *testing.T) {
+	defer func(level int) { logLevel = level }(logLevel)
+	openApiFile := filepath.Join(t.TempDir(), "pets.yaml")
+	if err := os.WriteFile(openApiFile, []byte(petSpec), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		target := t.TempDir()
+		if code := run([]string{"--quiet", "--port=9123", openApiFile, target}); code != ExitOK {
+			t.Fatalf("expect success, got exit code %d", code)
+		}
+		data, err := os.ReadFile(filepath.Join(target, "data", "Pet_API", "setting.yaml"))
+		if err != nil {
+			t.Fatalf("Failed to read setting: %v", err)
+		}
+		if !strings.Contains(string(data), "port: 9123\n") {
+			t.Errorf("expect the fixed port in the setting, got:\n%s", data)
+		}
+	}
+}
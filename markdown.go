@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// markdownExtensions are the extensions of the Markdown files the spec is
+// extracted from, see extractMarkdownSpec.
+var markdownExtensions = map[string]bool{".md": true, ".markdown": true}
+
+// openApiMarkerPattern matches the line declaring the version of an OpenAPI or
+// Swagger document, in YAML or JSON.
+var openApiMarkerPattern = regexp.MustCompile(`^\s*"?(openapi|swagger)"?\s*:`)
+
+// isMarkdownFile reports whether a file is a Markdown document, by extension.
+func isMarkdownFile(path string) bool {
+	return markdownExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// extractMarkdownSpec returns the content of the first fenced code block of a
+// Markdown document declaring an openapi or swagger version.
+func extractMarkdownSpec(data []byte) ([]byte, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+
+	fence := ""
+	var block bytes.Buffer
+	isSpec := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if fence == "" {
+			// Look for the opening fence of a code block
+			for _, marker := range []string{"```", "~~~"} {
+				if strings.HasPrefix(trimmed, marker) {
+					fence = marker
+					block.Reset()
+					isSpec = false
+				}
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+			if isSpec {
+				return block.Bytes(), nil
+			}
+			fence = ""
+			continue
+		}
+		if openApiMarkerPattern.MatchString(line) {
+			isSpec = true
+		}
+		block.WriteString(line + "\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("no code block with an OpenAPI document found in the Markdown file")
+}
+
+// markdownFormatting are the patterns of the Markdown formatting removed by
+// plainText, with the replacement keeping their text, in order.
+var markdownFormatting = []struct {
+	pattern     *regexp.Regexp
+	replacement string
+}{
+	{regexp.MustCompile(`(?m)^[ \t]{0,3}(#{1,6}|>|[-*+]|\d+\.)[ \t]+`), ""}, // headings, quotes and list items
+	{regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`), "$1"},                   // links and images
+	{regexp.MustCompile(`!?\[([^\]]*)\]\[[^\]]*\]`), "$1"},                  // reference links
+	{regexp.MustCompile(`<(https?://[^>]+)>`), "$1"},                        // autolinks
+	{regexp.MustCompile(`</?[A-Za-z][^>]*>`), ""},                           // HTML tags
+	{regexp.MustCompile("`+([^`]*)`+"), "$1"},                               // code spans
+	{regexp.MustCompile(`(\*\*|__|~~)(\S(?:.*?\S)?)(\*\*|__|~~)`), "$2"},    // strong and strikethrough
+	{regexp.MustCompile(`(^|[^\w*])\*(\S(?:.*?\S)?)\*`), "$1$2"},            // emphasis with stars
+	{regexp.MustCompile(`(^|\W)_(\S(?:.*?\S)?)_(\W|$)`), "$1$2$3"},          // emphasis with underscores
+}
+
+// plainText strips the Markdown formatting of a text, e.g. a description,
+// keeping the text of the links and the emphasis.
+func plainText(text string) string {
+	for _, format := range markdownFormatting {
+		text = format.pattern.ReplaceAllString(text, format.replacement)
+	}
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseOpenApiFileMarkdown(t *testing.T) {
+	document := "# Pet API\n\nAn example request:\n\n```json\n{\"name\": \"Tom\"}\n```\n\nThe spec:\n\n```yaml\n" + petSpec + "```\n"
+	openApiFile := filepath.Join(t.TempDir(), "design.md")
+	if err := os.WriteFile(openApiFile, []byte(document), 0644); err != nil {
+		t.Fatalf("Failed to write document: %v", err)
+	}
+
+	spec, err := ParseOpenApiFile(openApiFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if spec.Info == nil || spec.Info.Title != "Pet API" {
+		t.Errorf("expect the spec of the code block, got %+v", spec.Info)
+	}
+
+	if _, err := extractMarkdownSpec([]byte("# No spec\n\n```yaml\nname: Tom\n```\n")); err == nil {
+		t.Errorf("expect error without an OpenAPI code block")
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	cases := map[string]string{
+		"Returns the **current** user":                   "Returns the current user",
+		"See [the docs](https://example.com/docs) first": "See the docs first",
+		"The `user_id` is _required_":                    "The user_id is required",
+		"## Not found\n\n* no pet with the id":           "Not found no pet with the id",
+		"Visit <https://example.com> or ~~never~~":       "Visit https://example.com or never",
+		"Keeps snake_case_names and 2 * 3":               "Keeps snake_case_names and 2 * 3",
+	}
+	for markdown, expected := range cases {
+		if text := plainText(markdown); text != expected {
+			t.Errorf("Expected the plain text of %q to be %q, got %q", markdown, expected, text)
+		}
+	}
+}
+
+func TestPlainNames(t *testing.T) {
+	spec := `
+openapi: "3.0.0"
+info:
+  title: Markdown API
+  version: 1.0.0
+paths:
+  /me:
+    get:
+      responses:
+        "200":
+          description: "**Current** user, see [profile](https://example.com/profile)"
+`
+	setting := ConvertOpenAPIToMockServer(loadTestSpec(t, spec), Options{PlainNames: true})
+	if name := setting.Requests[0].Responses[0].Name; name != "Current_user,_see_profile" {
+		t.Errorf("Expected a plain text name, got %q", name)
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// mergeKeptSettingKeys are the keys of the setting kept from the existing
+// setting file, as they are usually edited by hand.
+var mergeKeptSettingKeys = map[string]bool{"host": true, "port": true, "swaggerEnabled": true, "headers": true}
+
+// mergeGeneratedSettingKeys are the keys of the setting always taken from the
+// new setting, even when it omits them.
+var mergeGeneratedSettingKeys = map[string]bool{"name": true, "description": true, "servers": true}
+
+// mergeKeptResponseKeys are the keys of a response kept from the existing
+// setting file, as they are usually edited by hand.
+var mergeKeptResponseKeys = map[string]bool{"query": true, "headers": true}
+
+// loadExistingSetting reads the setting file previously saved at path. It
+// returns nil when there is no such file.
+func loadExistingSetting(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read existing mock server setting: %w", err)
+	}
+
+	// JSON is valid YAML, so both setting formats are read the same way
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse existing mock server setting: %w", err)
+	}
+	if len(document.Content) == 0 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse existing mock server setting: %s is not a mapping", path)
+	}
+	expandAliases(document.Content[0])
+	return document.Content[0], nil
+}
+
+// expandAliases replaces the aliases under a node with the nodes they refer
+// to and drops the anchors, so that the merged nodes can be anchored again.
+func expandAliases(node *yaml.Node) {
+	node.Anchor = ""
+	for i, child := range node.Content {
+		if child.Kind == yaml.AliasNode {
+			expanded := *child.Alias
+			node.Content[i] = &expanded
+			child = &expanded
+		}
+		expandAliases(child)
+	}
+}
+
+// mergeSetting merges the existing setting into the node of the new setting.
+// Requests and responses still in the spec keep the fields edited by hand,
+// including ones the generator does not know about, e.g. delays, while the
+// generated fields are updated. Requests no longer in the spec are kept unless
+// prune is set.
+func mergeSetting(existing, node *yaml.Node, prune bool) {
+	for i := 0; i+1 < len(existing.Content); i += 2 {
+		key, value := existing.Content[i].Value, existing.Content[i+1]
+		switch {
+		case key == "requests":
+			requests := mappingValue(node, "requests")
+			if requests == nil {
+				continue
+			}
+			mergeRequests(value, requests, prune)
+		case mergeKeptSettingKeys[key] || !mergeGeneratedSettingKeys[key] && mappingValue(node, key) == nil:
+			setMappingValue(node, key, value)
+		}
+	}
+}
+
+// mergeRequests merges the existing requests into the new ones, matching them
+// by method and path.
+func mergeRequests(existing, requests *yaml.Node, prune bool) {
+	previous := map[string][]*yaml.Node{}
+	for _, request := range existing.Content {
+		key := requestKey(request)
+		previous[key] = append(previous[key], request)
+	}
+
+	merged := map[*yaml.Node]bool{}
+	for i, request := range requests.Content {
+		key := requestKey(request)
+		if len(previous[key]) == 0 {
+			continue
+		}
+		old := previous[key][0]
+		previous[key] = previous[key][1:]
+		merged[old] = true
+		requests.Content[i] = mergeRequest(old, request)
+	}
+
+	if prune {
+		return
+	}
+
+	// Keep the requests removed from the spec, in their previous order
+	for _, request := range existing.Content {
+		if !merged[request] {
+			requests.Content = append(requests.Content, request)
+		}
+	}
+}
+
+// mergeRequest returns the existing request updated with the generated fields
+// of the new one.
+func mergeRequest(existing, request *yaml.Node) *yaml.Node {
+	for i := 0; i+1 < len(request.Content); i += 2 {
+		key, value := request.Content[i].Value, request.Content[i+1]
+		if key == "responses" {
+			if responses := mappingValue(existing, "responses"); responses != nil {
+				mergeResponses(responses, value)
+			}
+		}
+		setMappingValue(existing, key, value)
+	}
+
+	// Generated fields missing from the new request are dropped
+	for _, key := range []string{"description", "webhook", "callback", "requestExamples"} {
+		if mappingValue(request, key) == nil {
+			deleteMappingValue(existing, key)
+		}
+	}
+	return existing
+}
+
+// mergeResponses merges the existing responses into the new ones, matching
+// them by code, name and body file.
+func mergeResponses(existing, responses *yaml.Node) {
+	previous := map[string][]*yaml.Node{}
+	for _, response := range existing.Content {
+		key := responseKey(response)
+		previous[key] = append(previous[key], response)
+	}
+
+	for i, response := range responses.Content {
+		key := responseKey(response)
+		if len(previous[key]) == 0 {
+			continue
+		}
+		old := previous[key][0]
+		previous[key] = previous[key][1:]
+
+		for j := 0; j+1 < len(response.Content); j += 2 {
+			key, value := response.Content[j].Value, response.Content[j+1]
+			if mergeKeptResponseKeys[key] && mappingValue(old, key) != nil {
+				continue
+			}
+			setMappingValue(old, key, value)
+		}
+		for _, key := range []string{"key", "filePath", "default", "links"} {
+			if mappingValue(response, key) == nil {
+				deleteMappingValue(old, key)
+			}
+		}
+		responses.Content[i] = old
+	}
+}
+
+// requestKey identifies a request node across generations.
+func requestKey(request *yaml.Node) string {
+	return fmt.Sprintf("%s %s %s",
+		strings.ToUpper(scalarValue(request, "method")),
+		scalarValue(request, "path"),
+		scalarValue(request, "callback"))
+}
+
+// responseKey identifies a response node across generations.
+func responseKey(response *yaml.Node) string {
+	return fmt.Sprintf("%s %s %s",
+		scalarValue(response, "code"),
+		scalarValue(response, "name"),
+		scalarValue(response, "filePath"))
+}
+
+// scalarValue returns the value of a scalar key of a mapping node, or an empty
+// string.
+func scalarValue(node *yaml.Node, key string) string {
+	value := mappingValue(node, key)
+	if value == nil {
+		return ""
+	}
+	return value.Value
+}
+
+// mappingValue returns the value node of a key of a mapping node, or nil.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// setMappingValue sets the value node of a key of a mapping node, appending
+// the key when missing.
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// deleteMappingValue removes a key from a mapping node.
+func deleteMappingValue(node *yaml.Node, key string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+// nodeValue converts a node into a value marshaled to JSON with the keys of
+// the mappings in order.
+func nodeValue(node *yaml.Node) (interface{}, error) {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+		return nodeValue(node.Content[0])
+	case yaml.AliasNode:
+		return nodeValue(node.Alias)
+	case yaml.MappingNode:
+		object := NewOrderedMap()
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			value, err := nodeValue(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+			object.Set(node.Content[i].Value, value)
+		}
+		return object, nil
+	case yaml.SequenceNode:
+		list := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			value, err := nodeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+	default:
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+}
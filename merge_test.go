@@ -0,0 +1,175 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const mergeSpec = `
+openapi: "3.0.0"
+info:
+  title: Merge API
+  version: 1.0.0
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              example:
+                name: Tom
+  /owners:
+    get:
+      operationId: listOwners
+      responses:
+        "204":
+          description: No Content
+`
+
+const mergedSpec = `
+openapi: "3.0.0"
+info:
+  title: Merge API
+  version: 1.0.0
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              example:
+                name: Jerry
+  /toys:
+    get:
+      operationId: listToys
+      responses:
+        "204":
+          description: No Content
+`
+
+// generateInto generates the mock server of a spec into a target folder and
+// returns the saved setting.
+func generateInto(t *testing.T, targetFolder, spec string, opts Options) (MockServerSetting, map[string]interface{}) {
+	t.Helper()
+	setting := ConvertOpenAPIToMockServer(loadTestSpec(t, spec), opts)
+	if err := setting.CreateFolder(targetFolder); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	if err := setting.SaveSetting(); err != nil {
+		t.Fatalf("Failed to save setting: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(setting.Folder, "setting.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read setting: %v", err)
+	}
+	var saved map[string]interface{}
+	if err := yaml.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("Failed to parse setting: %v", err)
+	}
+	return setting, saved
+}
+
+// editSetting rewrites the setting of a mock server folder with edit.
+func editSetting(t *testing.T, folder string, edit func(setting map[string]interface{})) {
+	t.Helper()
+	path := filepath.Join(folder, "setting.yaml")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read setting: %v", err)
+	}
+	var setting map[string]interface{}
+	if err := yaml.Unmarshal(data, &setting); err != nil {
+		t.Fatalf("Failed to parse setting: %v", err)
+	}
+	edit(setting)
+	if data, err = yaml.Marshal(setting); err != nil {
+		t.Fatalf("Failed to marshal setting: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatalf("Failed to write setting: %v", err)
+	}
+}
+
+// savedRequest returns the request of a setting with a path.
+func savedRequest(setting map[string]interface{}, path string) map[string]interface{} {
+	for _, request := range setting["requests"].([]interface{}) {
+		if request := request.(map[string]interface{}); request["path"] == path {
+			return request
+		}
+	}
+	return nil
+}
+
+// savedRequestPaths returns the sorted paths of the requests of a setting.
+func savedRequestPaths(setting map[string]interface{}) []string {
+	var paths []string
+	for _, request := range setting["requests"].([]interface{}) {
+		paths = append(paths, request.(map[string]interface{})["path"].(string))
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func TestMergeKeepsManualFields(t *testing.T) {
+	targetFolder := t.TempDir()
+	opts := Options{Port: 8080, Merge: true}
+	setting, _ := generateInto(t, targetFolder, mergeSpec, opts)
+
+	editSetting(t, setting.Folder, func(saved map[string]interface{}) {
+		saved["port"] = 9090
+		request := savedRequest(saved, "/pets/{id}")
+		request["delay"] = 500
+		response := request["responses"].([]interface{})[0].(map[string]interface{})
+		response["headers"] = []interface{}{
+			map[string]interface{}{"name": "X-Manual", "value": "kept"},
+		}
+	})
+
+	_, saved := generateInto(t, targetFolder, mergedSpec, opts)
+
+	if saved["port"] != 9090 {
+		t.Errorf("Expected the edited port to be kept, got %v", saved["port"])
+	}
+	if paths := strings.Join(savedRequestPaths(saved), ","); paths != "/owners,/pets/{id},/toys" {
+		t.Errorf("Expected the new route added and the removed one kept, got %s", paths)
+	}
+
+	request := savedRequest(saved, "/pets/{id}")
+	if request["delay"] != 500 {
+		t.Errorf("Expected the delay to be kept, got %v", request["delay"])
+	}
+	response := request["responses"].([]interface{})[0].(map[string]interface{})
+	headers := response["headers"].([]interface{})
+	if len(headers) != 1 || headers[0].(map[string]interface{})["name"] != "X-Manual" {
+		t.Errorf("Expected the manual header to be kept, got %v", headers)
+	}
+
+	body, err := os.ReadFile(filepath.Join(targetFolder, response["filePath"].(string)))
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	if !strings.Contains(string(body), "Jerry") {
+		t.Errorf("Expected the body to be updated from the spec, got %s", body)
+	}
+}
+
+func TestMergePrunesRemovedRequests(t *testing.T) {
+	targetFolder := t.TempDir()
+	generateInto(t, targetFolder, mergeSpec, Options{Port: 8080})
+	_, saved := generateInto(t, targetFolder, mergedSpec, Options{Port: 8080, Merge: true, Prune: true})
+
+	if paths := strings.Join(savedRequestPaths(saved), ","); paths != "/pets/{id},/toys" {
+		t.Errorf("Expected the removed route to be pruned, got %s", paths)
+	}
+}
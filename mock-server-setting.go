@@ -3,12 +3,20 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"gopkg.in/yaml.v3"
@@ -17,121 +25,615 @@ import (
 // MockServerSetting defines the structure of mock server.
 
 type MockServerSetting struct {
-	Name           string            `yaml:"name"`
-	Description    string            `yaml:"description"`
-	Folder         string            `yaml:"-"` // Folder is not saved in the YAML file
-	Host           string            `yaml:"host"`
-	Port           int               `yaml:"port"`
-	SwaggerEnabled bool              `yaml:"swaggerEnabled"`
-	Headers        *[]Header         `yaml:"headers,omitempty"`
-	Requests       []Request         `yaml:"requests"`
-	Schemas        map[string]string `yaml:"-"`
+	Name           string              `yaml:"name" json:"name"`
+	Description    string              `yaml:"description,omitempty" json:"description,omitempty"`
+	Folder         string              `yaml:"-" json:"-"` // Folder is not saved in the setting file
+	TargetFolder   string              `yaml:"-" json:"-"` // TargetFolder is the folder the mock server is written into
+	FolderName     string              `yaml:"-" json:"-"` // FolderName is the name of the folder of the mock server
+	Host           string              `yaml:"host" json:"host"`
+	Port           int                 `yaml:"port" json:"port"`
+	Servers        []string            `yaml:"servers,omitempty" json:"servers,omitempty"`
+	SwaggerEnabled bool                `yaml:"swaggerEnabled" json:"swaggerEnabled"`
+	Headers        *[]Header           `yaml:"headers,omitempty" json:"headers,omitempty"`
+	Requests       []Request           `yaml:"requests" json:"requests"`
+	Schemas        map[string]string   `yaml:"-" json:"-"`
+	Paths          map[string]PathInfo `yaml:"-" json:"-"` // Paths are the summary and description of each path
+	Options        Options             `yaml:"-" json:"-"` // Options are not saved in the setting file
+	Spec           *openapi3.T         `yaml:"-" json:"-"` // Spec is the converted OpenAPI document
+}
+
+// PathInfo is the summary and description of a path item, shared by its operations.
+type PathInfo struct {
+	Summary     string
+	Description string
 }
 
 type Request struct {
-	Name      string     `yaml:"name"`
-	Method    string     `yaml:"method"`
-	Path      string     `yaml:"path"`
-	Responses []Response `yaml:"responses"`
+	Name        string           `yaml:"name" json:"name"`
+	Description string           `yaml:"description,omitempty" json:"description,omitempty"`
+	Method      string           `yaml:"method" json:"method"`
+	Path        string           `yaml:"path" json:"path"`
+	Webhook     bool             `yaml:"webhook,omitempty" json:"webhook,omitempty"`
+	Callback    string           `yaml:"callback,omitempty" json:"callback,omitempty"`
+	Responses   []Response       `yaml:"responses" json:"responses"`
+	Examples    []RequestExample `yaml:"requestExamples,omitempty" json:"requestExamples,omitempty"`
+
+	Operation *openapi3.Operation `yaml:"-" json:"-"` // Operation the request is generated from
 }
 
 type Response struct {
-	Name     string    `yaml:"name"`
-	Code     int       `yaml:"code"`
-	Query    string    `yaml:"query,omitempty"`
-	Headers  *[]Header `yaml:"headers,omitempty"`
-	FilePath *string   `yaml:"filePath,omitempty"`
-	Body     *string   `yaml:"-"` // Body is not saved in the YAML file
+	Name       string    `yaml:"name" json:"name"`
+	Code       int       `yaml:"code" json:"code"`
+	Key        string    `yaml:"key,omitempty" json:"key,omitempty"` // Key is the response key of the spec when not a status code, e.g. default or 4XX
+	Query      string    `yaml:"query,omitempty" json:"query,omitempty"`
+	Headers    *[]Header `yaml:"headers,omitempty" json:"headers,omitempty"`
+	FilePath   *string   `yaml:"filePath,omitempty" json:"filePath,omitempty"`
+	DelayMinMs int       `yaml:"delayMinMs,omitempty" json:"delayMinMs,omitempty"` // DelayMinMs is the minimum latency, in milliseconds
+	DelayMaxMs int       `yaml:"delayMaxMs,omitempty" json:"delayMaxMs,omitempty"` // DelayMaxMs is the maximum latency, in milliseconds
+	Links      []Link    `yaml:"links,omitempty" json:"links,omitempty"`           // Links are the operations the response leads to
+	Default    bool      `yaml:"default,omitempty" json:"default,omitempty"`       // Default is returned when no selector is given
+	Body       *string   `yaml:"-" json:"-"`                                       // Body is not saved in the setting file
+
+	Description string `yaml:"-" json:"-"` // Description of the response in the spec
+	PadBytes    int    `yaml:"-" json:"-"` // PadBytes is the size the body is padded up to
+	Fixture     string `yaml:"-" json:"-"` // Fixture is the name of the shared fixture the body comes from
+}
+
+// ContentType returns the value of the Content-Type header of the response.
+func (r Response) ContentType() string {
+	if r.Headers == nil {
+		return ""
+	}
+	for _, header := range *r.Headers {
+		if strings.EqualFold(header.Name, "Content-Type") {
+			return header.Value
+		}
+	}
+	return ""
 }
 
 type Header struct {
-	Name  string `yaml:"name"`
-	Value string `yaml:"value"`
+	Name  string `yaml:"name" json:"name"`
+	Value string `yaml:"value" json:"value"`
 }
 
-func ParseOpenApiFile(openApiFile string) openapi3.T {
+// ParseOpenApiFile reads and parses an OpenAPI file, without resolving its
+// external references. The errors carry the exit code of their category, see
+// exitCode.
+func ParseOpenApiFile(openApiFile string) (openapi3.T, error) {
+	return parseOpenApiFile(openApiFile, false)
+}
+
+// parseOpenApiFile reads and parses an OpenAPI file like ParseOpenApiFile. With
+// externalRefs, the references to other files or URLs are resolved relative to
+// the file, and a root document that only refers to another one is replaced by
+// it.
+func parseOpenApiFile(openApiFile string, externalRefs bool) (openapi3.T, error) {
 	data, err := os.ReadFile(openApiFile)
 	if err != nil {
-		log.Fatalf("Failed to read OpenAPI file: %v", err)
+		code := ExitParse
+		if os.IsNotExist(err) {
+			code = ExitSpecNotFound
+		}
+		return openapi3.T{}, withExitCode(code, fmt.Errorf("failed to read OpenAPI file: %w", err))
 	}
 
-	// Step 2: Parse the OpenAPI file.
-	loader := openapi3.NewLoader()
-	openAPISpec, err := loader.LoadFromData(data)
-	if err != nil || openAPISpec == nil {
-		log.Fatalf("Failed to parse OpenAPI file: %v", err)
+	// Extract the spec from the code blocks of a Markdown document
+	if isMarkdownFile(openApiFile) {
+		if data, err = decodeSpecData(data); err == nil {
+			data, err = extractMarkdownSpec(data)
+		}
+		if err != nil {
+			return openapi3.T{}, withExitCode(ExitParse, fmt.Errorf("failed to parse OpenAPI file: %w", err))
+		}
 	}
 
-	return *openAPISpec
+	// Step 2: Parse the OpenAPI file, resolving its external references relative
+	// to it when enabled.
+	var location *url.URL
+	if externalRefs {
+		location = &url.URL{Path: filepath.ToSlash(openApiFile)}
+		if absPath, err := filepath.Abs(openApiFile); err == nil {
+			location.Path = filepath.ToSlash(absPath)
+		}
+	}
+	openAPISpec, err := loadOpenApiDocument(data, location)
+	if err != nil {
+		return openapi3.T{}, fmt.Errorf("failed to parse OpenAPI file: %w", err)
+	}
+
+	return *openAPISpec, nil
+}
+
+// loadOpenApiData parses an OpenAPI document, after stripping its byte order
+// mark or transcoding it from UTF-16. Unsupported versions are rejected, and
+// parse errors are reported with the line and column of the document they occur
+// at, when available.
+func loadOpenApiData(data []byte) (*openapi3.T, error) {
+	return loadOpenApiDocument(data, nil)
+}
+
+// loadOpenApiDocument parses an OpenAPI document like loadOpenApiData. When the
+// location of the document is given, its external references are resolved
+// relative to it, and a root document that only refers to another one is
+// replaced by it.
+func loadOpenApiDocument(data []byte, location *url.URL) (*openapi3.T, error) {
+	data, err := decodeSpecData(data)
+	if err != nil {
+		return nil, withExitCode(ExitParse, err)
+	}
+	loader := openapi3.NewLoader()
+	if location != nil {
+		loader.IsExternalRefsAllowed = true
+		if data, location, err = followRootRef(loader, data, location); err != nil {
+			return nil, withExitCode(ExitParse, err)
+		}
+	}
+	if err := checkOpenApiVersion(data); err != nil {
+		return nil, withExitCode(ExitValidation, err)
+	}
+	var openAPISpec *openapi3.T
+	if location != nil {
+		openAPISpec, err = loader.LoadFromDataWithPath(data, location)
+	} else {
+		openAPISpec, err = loader.LoadFromData(data)
+	}
+	if err != nil {
+		return nil, withExitCode(ExitParse, locateParseError(data, err))
+	}
+	if openAPISpec == nil {
+		return nil, withExitCode(ExitParse, fmt.Errorf("empty OpenAPI document"))
+	}
+	return openAPISpec, nil
 }
 
 // ConvertOpenAPIToCustomFormat converts an OpenAPI spec to mock server.
-func ConvertOpenAPIToMockServer(openAPISpec openapi3.T) MockServerSetting {
+func ConvertOpenAPIToMockServer(openAPISpec openapi3.T, opts Options) MockServerSetting {
 	headers := getHeaders(openAPISpec)
-	requests := getRequests(openAPISpec)
+	seed := conversionSeed(opts, openAPISpec)
+	opts.random = newRandom(seed)
+	schemaExamples := getSchemaExamples(openAPISpec, opts)
+	requests := getRequests(openAPISpec, schemaExamples, opts)
+	paths := getPathInfos(openAPISpec, opts)
+	if opts.TrimCommonPrefix {
+		if prefix := commonPathPrefix(requests); prefix != "" {
+			infof("Trimming the common path prefix %s", prefix)
+			paths = trimPathPrefix(prefix, requests, paths)
+		}
+	}
+	shareFixtures(requests)
+	if opts.EmitHealth {
+		requests = addHealthRequest(requests, opts.HealthPath, opts)
+	}
+	servers := getServers(openAPISpec)
+
+	// The primary host and port come from the first server, if it names them
+	host, port := "0.0.0.0", randomPort(seed)
+	if len(servers) > 0 {
+		if serverHost, serverPort := serverHostPort(servers[0]); serverPort != 0 {
+			port = serverPort
+			if serverHost != "" {
+				host = serverHost
+			}
+		}
+	}
+	if opts.Host != "" {
+		host = opts.Host
+	}
+	if opts.Port != 0 {
+		port = opts.Port
+	}
+
+	// The description of the spec can be replaced, or omitted when too large
+	description := openAPISpec.Info.Description
+	if opts.Description != "" {
+		description = opts.Description
+	}
+	if opts.NoDescription {
+		description = ""
+	}
+
 	return MockServerSetting{
 		Name:           openAPISpec.Info.Title,
-		Description:    openAPISpec.Info.Description,
-		Host:           "0.0.0.0",
-		Port:           randomPort(),
+		Description:    description,
+		Host:           host,
+		Port:           port,
+		Servers:        servers,
 		SwaggerEnabled: true,
 		Headers:        &headers,
 		Requests:       requests,
+		Schemas:        schemaExamples,
+		Paths:          paths,
+		Options:        opts,
+		Spec:           &openAPISpec,
+	}
+}
+
+// randomPort picks a port from 10000 to 60000 with the random source seeded by
+// the conversion seed, so that a spec keeps its port from run to run. The port
+// is not probed, as whether it is free depends on the machine generating the
+// mock server, not the one running it; --port or --base-port fix a port.
+func randomPort(seed int64) int {
+	return 10000 + newRandom(seed).Intn(50001)
+}
+
+// getServers returns the URLs of the servers declared in the OpenAPI spec, in order.
+// Server variables are replaced with their default values.
+func getServers(openAPISpec openapi3.T) []string {
+	servers := []string{}
+	for _, server := range openAPISpec.Servers {
+		if server == nil || server.URL == "" {
+			continue
+		}
+		serverURL := server.URL
+		for name, variable := range server.Variables {
+			if variable != nil {
+				serverURL = strings.ReplaceAll(serverURL, "{"+name+"}", variable.Default)
+			}
+		}
+		servers = append(servers, serverURL)
 	}
+	return servers
 }
 
-// RandomPort generates a random port number from 10000 to 60000.
-func randomPort() int {
-	return 10000 + (os.Getpid() % 50000)
+// serverHostPort returns the host and port a mock server should listen on for the
+// given server URL. The port is 0 if the URL does not name one explicitly, and the
+// host is empty unless it is a local address the mock server can bind to.
+func serverHostPort(serverURL string) (string, int) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return "", 0
+	}
+	port, err := strconv.Atoi(u.Port())
+	if err != nil {
+		return "", 0
+	}
+	host := u.Hostname()
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !(ip.IsLoopback() || ip.IsUnspecified())) {
+		host = ""
+	}
+	return host, port
 }
 
+// addHealthRequest appends a health-check route returning {"status":"ok"} to the
+// requests, unless a GET route already exists on that path.
+func addHealthRequest(requests []Request, path string, opts Options) []Request {
+	if path == "" {
+		path = "/healthz"
+	}
+	for _, request := range requests {
+		if strings.EqualFold(request.Method, "GET") && request.Path == path {
+			return requests
+		}
+	}
+
+	body := "{\n  \"status\": \"ok\"\n}"
+	return append(requests, Request{
+		Name:   "healthCheck",
+		Method: "GET",
+		Path:   path,
+		Responses: []Response{
+			{
+				Name:    "OK",
+				Code:    200,
+				Default: true,
+				Query:   opts.selectorQuery(SelectorKey, "200", SelectorContentType, "application/json"),
+				Headers: &[]Header{{Name: "Content-Type", Value: "application/json"}},
+				Body:    &body,
+			},
+		},
+	})
+}
+
+// getHeaders returns placeholder headers for the security schemes of the
+// spec: the header of the apiKey schemes in header and the Authorization
+// header of the http bearer schemes. They are deduplicated by name and sorted.
 func getHeaders(openAPISpec openapi3.T) []Header {
-	return []Header{}
+	headers := []Header{}
+	if openAPISpec.Components == nil {
+		return headers
+	}
+	seen := map[string]bool{}
+	for _, name := range sortedKeys(openAPISpec.Components.SecuritySchemes) {
+		schemeRef := openAPISpec.Components.SecuritySchemes[name]
+		if schemeRef == nil || schemeRef.Value == nil {
+			continue
+		}
+		scheme := schemeRef.Value
+		var header Header
+		switch {
+		case scheme.Type == "apiKey" && scheme.In == "header" && scheme.Name != "":
+			header = Header{Name: scheme.Name, Value: "<value>"}
+		case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "bearer"):
+			header = Header{Name: "Authorization", Value: "Bearer <token>"}
+		default:
+			continue
+		}
+		if key := http.CanonicalHeaderKey(header.Name); !seen[key] {
+			seen[key] = true
+			headers = append(headers, header)
+		}
+	}
+	sort.Slice(headers, func(i, j int) bool { return headers[i].Name < headers[j].Name })
+	return headers
 }
 
-// getRequests extracts the requests from the OpenAPI spec.
-func getRequests(openAPISpec openapi3.T) (requests []Request) {
-	// Loop through the components
+// getSchemaExamples generates an example for each component schema of the OpenAPI
+// spec, keyed by the schema reference, e.g. "#/components/schemas/User".
+func getSchemaExamples(openAPISpec openapi3.T, opts Options) map[string]string {
+	// Loop through the components, in the order of their names so that the
+	// random source gives the same examples on each run
 	schemaExamples := make(map[string]string)
 	if openAPISpec.Components != nil && openAPISpec.Components.Schemas != nil {
-		for schemaName, schemaRef := range openAPISpec.Components.Schemas {
-			schema := schemaRef.Value
+		for _, schemaName := range sortedKeys(openAPISpec.Components.Schemas) {
+			schema := openAPISpec.Components.Schemas[schemaName].Value
 			// Extract the schema
 			schemaFullName := fmt.Sprintf("#/components/schemas/%s", schemaName)
-			schemaExample := extractSchemaExample(schema)
+			schemaExample := extractSchemaExample(schema, opts)
 			schemaExamples[schemaFullName] = schemaExample
 		}
 	}
-	// Loop through the paths
+	return schemaExamples
+}
+
+// getRequests extracts the requests from the OpenAPI spec.
+func getRequests(openAPISpec openapi3.T, schemaExamples map[string]string, opts Options) (requests []Request) {
+	// Loop through the paths, in the order of their keys so that the requests
+	// and their examples are the same on each run
+	pathItems := openAPISpec.Paths.Map()
+	for _, path := range sortedKeys(pathItems) {
+		pathItem := pathItems[path]
+		if cleanPath, query := stripPathQuery(path); query != "" {
+			warnf("Path %s has an embedded query string, routed as %s", path, cleanPath)
+			path = cleanPath
+		}
+		requests = append(requests, pathItemRequests(path, pathItem, schemaExamples, opts)...)
+	}
+
+	// Rewrite the paths of the requests, but not the expressions of the callbacks
+	for i, request := range requests {
+		if request.Callback == "" {
+			if path := rewritePath(request.Path, opts.Rewrites); path != request.Path {
+				infof("Path %s is rewritten to %s", request.Path, path)
+				requests[i].Path = path
+			}
+		}
+	}
+
+	// Loop through the webhooks, the requests they describe are received at /<name>
+	webhooks := getWebhooks(openAPISpec)
+	for _, name := range sortedKeys(webhooks) {
+		webhookRequests := pathItemRequests("/"+name, webhooks[name], schemaExamples, opts)
+		for i := range webhookRequests {
+			webhookRequests[i].Webhook = true
+		}
+		requests = append(requests, webhookRequests...)
+	}
+	return requests
+}
+
+// checkOperation reports an operation id the spec does not define, listing the
+// ones it defines.
+func checkOperation(openAPISpec openapi3.T, operationID string) error {
+	ids := []string{}
+	pathItems := []*openapi3.PathItem{}
+	for _, pathItem := range openAPISpec.Paths.Map() {
+		pathItems = append(pathItems, pathItem)
+	}
+	for _, pathItem := range getWebhooks(openAPISpec) {
+		pathItems = append(pathItems, pathItem)
+	}
+	for _, pathItem := range pathItems {
+		for _, operation := range pathItem.Operations() {
+			if operation.OperationID == operationID {
+				return nil
+			}
+			if operation.OperationID != "" {
+				ids = append(ids, operation.OperationID)
+			}
+		}
+	}
+	sort.Strings(ids)
+	return fmt.Errorf("operation %q not found, available operations: %s", operationID, strings.Join(ids, ", "))
+}
+
+// stripPathQuery splits the query string some malformed specs embed in a path
+// key, e.g. /search?type=x, from the path.
+func stripPathQuery(path string) (string, string) {
+	cleanPath, query, _ := strings.Cut(path, "?")
+	return cleanPath, query
+}
+
+// getPathInfos returns the summary and description of the path items of the spec
+// that have any, by path.
+func getPathInfos(openAPISpec openapi3.T, opts Options) map[string]PathInfo {
+	paths := map[string]PathInfo{}
 	for path, pathItem := range openAPISpec.Paths.Map() {
-		for method, operation := range pathItem.Operations() {
-			fmt.Printf("Path: %s, Method: %s, Operation: %s\n", path, method, operation.OperationID)
+		path, _ = stripPathQuery(path)
+		path = rewritePath(path, opts.Rewrites)
+		if pathItem.Summary != "" || pathItem.Description != "" {
+			paths[path] = PathInfo{Summary: pathItem.Summary, Description: pathItem.Description}
+		}
+	}
+	return paths
+}
+
+// pathItemRequests extracts a request for each operation of a path item.
+func pathItemRequests(path string, pathItem *openapi3.PathItem, schemaExamples map[string]string, opts Options) (requests []Request) {
+	operations := pathItem.Operations()
+	for _, method := range sortedKeys(operations) {
+		operation := operations[method]
+		if opts.Operation != "" && operation.OperationID != opts.Operation {
+			continue
+		}
+		if opts.ExcludeDeprecated && operation.Deprecated {
+			infof("Path: %s, Method: %s, Operation: %s is deprecated, skipped", path, method, operation.OperationID)
+			continue
+		}
+		infof("Path: %s, Method: %s, Operation: %s", path, method, operation.OperationID)
 
-			// Extract the responses
-			responses := extractResponse(operation, schemaExamples)
+		// Extract the responses
+		responses := extractResponse(operation, schemaExamples, opts)
+		detectContentTypes(responses)
+		if contentType, ok := contentTypeOverride(opts.ContentTypes, method, path); ok {
+			overrideContentType(responses, contentType)
+		}
+		formatEventStreams(responses)
+		formatNDJSON(responses)
+		if strings.EqualFold(method, "POST") {
+			addCreatedBodies(responses, operation, schemaExamples, opts)
+		}
+		if len(opts.DefaultBodies) > 0 {
+			addDefaultBodies(responses, opts.DefaultBodies)
+		}
+		if !opts.SingleFile {
+			// Binary bodies cannot be stored in the single JSON document
+			addPlaceholderImages(responses)
+		}
+		padBodies(responses, opts.Pad)
 
-			// Sort responses by code
-			sort.Slice(responses, func(i, j int) bool {
-				return responses[i].Code < responses[j].Code
-			})
+		// Sort responses by code
+		sort.SliceStable(responses, func(i, j int) bool {
+			return responses[i].Code < responses[j].Code
+		})
+		markDefaultResponse(responses)
+
+		// Create a request object
+		request := Request{
+			Name:        operation.OperationID,
+			Description: operationDescription(operation),
+			Method:      method,
+			Path:        path,
+			Responses:   responses,
+			Operation:   operation,
+		}
+		if opts.EmitRequestExamples {
+			request.Examples = requestExamples(operation)
+		}
+		requests = append(requests, request)
 
-			// Create a request object
-			requests = append(requests, Request{
-				Name:      operation.OperationID,
-				Method:    method,
-				Path:      path,
-				Responses: responses,
-			})
+		// Add the requests the callbacks of the operation receive
+		if opts.EmitCallbacks {
+			requests = append(requests, callbackRequests(operation, schemaExamples, opts)...)
 		}
 	}
 	return requests
 }
 
-func extractResponse(operation *openapi3.Operation, schemaExamples map[string]string) []Response {
+// markDefaultResponse marks the first response of the lowest 2XX code, the one
+// returned when the request has no selector. The responses are sorted by code,
+// then by content type and example name, so the same one is marked each run.
+func markDefaultResponse(responses []Response) {
+	for i := range responses {
+		if responses[i].Code >= 200 && responses[i].Code < 300 {
+			responses[i].Default = true
+			return
+		}
+	}
+}
+
+// runtimeExpressionPattern matches the runtime expressions of a callback URL, e.g.
+// {$request.body#/callbackUrl}.
+var runtimeExpressionPattern = regexp.MustCompile(`\{\$[^}]*\}`)
+
+// callbackRequests extracts a request for each operation of the callbacks
+// declared on an operation.
+func callbackRequests(operation *openapi3.Operation, schemaExamples map[string]string, opts Options) (requests []Request) {
+	for _, name := range sortedKeys(operation.Callbacks) {
+		callbackRef := operation.Callbacks[name]
+		if callbackRef == nil || callbackRef.Value == nil {
+			continue
+		}
+		pathItems := callbackRef.Value.Map()
+		for _, expression := range sortedKeys(pathItems) {
+			callbackRequests := pathItemRequests(callbackPath(name, expression), pathItems[expression], schemaExamples, opts)
+			for i := range callbackRequests {
+				if callbackRequests[i].Callback == "" {
+					callbackRequests[i].Callback = name
+				}
+			}
+			requests = append(requests, callbackRequests...)
+		}
+	}
+	return requests
+}
+
+// callbackPath derives the path a callback is received at from its URL expression.
+// Runtime expressions are dropped and only the path of an absolute URL is kept,
+// e.g. "{$request.body#/callbackUrl}/events" becomes "/events". The callback name
+// is used when nothing is left.
+func callbackPath(name string, expression string) string {
+	path := runtimeExpressionPattern.ReplaceAllString(expression, "")
+	if u, err := url.Parse(path); err == nil && u.Host != "" {
+		path = u.Path
+	}
+	segments := strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+	path = "/" + strings.Join(segments, "/")
+	if path == "/" {
+		return "/" + name
+	}
+	return path
+}
+
+// getWebhooks returns the webhooks of an OpenAPI 3.1 spec. The loader keeps them
+// as a raw extension, so they are decoded here and their references are resolved
+// against the components of the spec.
+func getWebhooks(openAPISpec openapi3.T) map[string]*openapi3.PathItem {
+	raw, ok := openAPISpec.Extensions["webhooks"]
+	if !ok {
+		return nil
+	}
+
+	webhooks := map[string]*openapi3.PathItem{}
+	data, err := json.Marshal(raw)
+	if err == nil {
+		err = json.Unmarshal(data, &webhooks)
+	}
+	if err != nil {
+		warnf("failed to parse webhooks: %v", err)
+		return nil
+	}
+
+	// Resolve the references as if the webhooks were paths of the spec
+	doc := &openapi3.T{
+		OpenAPI:    openAPISpec.OpenAPI,
+		Info:       openAPISpec.Info,
+		Components: openAPISpec.Components,
+		Paths:      openapi3.NewPaths(),
+	}
+	for name, pathItem := range webhooks {
+		doc.Paths.Set("/"+name, pathItem)
+	}
+	if err := openapi3.NewLoader().ResolveRefsIn(doc, nil); err != nil {
+		warnf("failed to resolve webhook references: %v", err)
+		return nil
+	}
+	return webhooks
+}
+
+// operationDescription returns the description of an operation, or its summary
+// when the operation has no description.
+func operationDescription(operation *openapi3.Operation) string {
+	if description := strings.TrimSpace(operation.Description); description != "" {
+		return description
+	}
+	return strings.TrimSpace(operation.Summary)
+}
+
+func extractResponse(operation *openapi3.Operation, schemaExamples map[string]string, opts Options) []Response {
 	responses := []Response{}
 
-	// Loop through the responses
-	for response, responseItem := range operation.Responses.Map() {
+	// Loop through the responses, in the order of their keys so that responses
+	// sharing a code keep a stable order
+	responseMap := operation.Responses.Map()
+	keys := make([]string, 0, len(responseMap))
+	for key := range responseMap {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, response := range keys {
+		responseItem := responseMap[response]
+
 		// Get the description of the response
 		var description = ""
 		if responseItem.Value.Description != nil {
@@ -139,16 +641,24 @@ func extractResponse(operation *openapi3.Operation, schemaExamples map[string]st
 		}
 
 		// Get the response code
-		code, err := strconv.Atoi(response)
+		code, err := responseCode(response, keys, opts.DefaultCode)
 		if err != nil {
-			log.Fatalf("Failed to convert response code to integer: %v", err)
+			warnf("%v, response skipped", err)
+			continue
 		}
+		if len(opts.Codes) > 0 && !slices.Contains(opts.Codes, code) {
+			continue
+		}
+		first := len(responses)
 
 		// Get the content type
 		contentType := ""
-		if responseItem.Value != nil {
+		if csv, ok := csvResponse(response, code, description, responseItem.Value, opts); ok {
+			// The CSV file replaces the bodies generated from the content
+			responses = append(responses, csv)
+		} else if responseItem.Value != nil {
 			if responseItem.Value.Content != nil {
-				for contentType = range responseItem.Value.Content {
+				for _, contentType = range sortedKeys(responseItem.Value.Content) {
 					headers := []Header{
 						{Name: "Content-Type", Value: contentType},
 					}
@@ -158,66 +668,244 @@ func extractResponse(operation *openapi3.Operation, schemaExamples map[string]st
 					}
 					examples := content.Examples
 					schema := content.Schema
-					if len(examples) > 0 {
-						for exampleName, examapleObject := range examples {
-							bodyStr := getBodyString(examapleObject)
+
+					// Keep only the selected named example, when the response has it
+					if example, ok := examples[opts.ExampleName]; ok && opts.ExampleName != "" {
+						examples = openapi3.Examples{opts.ExampleName: example}
+					}
+
+					// The schema generates the body instead of the named examples
+					// when preferred, or when none of the examples has a value
+					hasSchema := schema != nil && (schema.Ref != "" || schema.Value != nil)
+					useSchema := hasSchema && (opts.PreferSchema || emptyExamples(examples))
+
+					// The singular example is a fallback for the named examples,
+					// unless it is preferred
+					if content.Example != nil && (len(examples) == 0 || opts.PreferExample) {
+						response := Response{
+							Name:        opts.responseName(description),
+							Description: description,
+							Code:        code,
+							Query:       opts.selectorQuery(SelectorKey, response, SelectorContentType, contentType),
+							Headers:     &headers,
+						}
+						if bodyStr := exampleBodyString(mediaTypeExample(content, opts)); len(bodyStr) > 0 {
+							if opts.ReparseStringExamples {
+								bodyStr = reparseStringExample(bodyStr, contentType)
+							}
+							response.Body = &bodyStr
+						}
+						responses = append(responses, response)
+					} else if len(examples) > 0 && !useSchema {
+						for _, exampleName := range sortedKeys(examples) {
+							examapleObject := examples[exampleName]
+							bodyStr := namedExampleBody(examapleObject, opts)
+							if opts.ReparseStringExamples {
+								bodyStr = reparseStringExample(bodyStr, contentType)
+							}
 
 							// Create a response object
 							response := Response{
-								Name:    cleanFolderName(description),
-								Code:    code,
-								Query:   "?key=" + response + "&contentType=" + contentType + "&name=" + exampleName,
-								Headers: &headers,
+								Name:        opts.responseName(description),
+								Description: description,
+								Code:        code,
+								Query:       opts.selectorQuery(SelectorKey, response, SelectorContentType, contentType, SelectorName, exampleName),
+								Headers:     &headers,
 							}
 							if len(bodyStr) > 0 {
 								response.Body = &bodyStr
 							}
 							responses = append(responses, response)
 						}
-					} else if schema != nil && schema.Ref != "" {
-						bodyStr, ok := schemaExamples[schema.Ref]
-						if ok && bodyStr != "" {
+					} else if hasSchema {
+						bodyStr := schemaBodyString(schema, schemaExamples, opts)
+						if bodyStr != "" {
 							responses = append(responses, Response{
-								Name:    cleanFolderName(description),
-								Code:    code,
-								Query:   "?key=" + response + "&contentType=" + contentType,
-								Headers: &headers,
-								Body:    &bodyStr,
+								Name:        opts.responseName(description),
+								Description: description,
+								Code:        code,
+								Query:       opts.selectorQuery(SelectorKey, response, SelectorContentType, contentType),
+								Headers:     &headers,
+								Body:        &bodyStr,
 							})
 						} else {
 							responses = append(responses, Response{
-								Name:    cleanFolderName(description),
-								Code:    code,
-								Query:   "?key=" + response + "&contentType=" + contentType,
-								Headers: &headers,
+								Name:        opts.responseName(description),
+								Description: description,
+								Code:        code,
+								Query:       opts.selectorQuery(SelectorKey, response, SelectorContentType, contentType),
+								Headers:     &headers,
 							})
 						}
 					} else {
 						responses = append(responses, Response{
-							Name:    cleanFolderName(description),
-							Code:    code,
-							Query:   "?key=" + response + "&contentType=" + contentType,
-							Headers: &headers,
+							Name:        opts.responseName(description),
+							Description: description,
+							Code:        code,
+							Query:       opts.selectorQuery(SelectorKey, response, SelectorContentType, contentType),
+							Headers:     &headers,
 						})
 					}
 				}
 			} else {
-				responses = append(responses, Response{
-					Name:  cleanFolderName(description),
-					Code:  code,
-					Query: "?key=" + strconv.Itoa(code),
-				})
+				noContent := Response{
+					Name:        opts.responseName(description),
+					Description: description,
+					Code:        code,
+					Query:       opts.selectorQuery(SelectorKey, response),
+				}
+
+				// A schema or an example declared without a media type still
+				// gives a body
+				if body, ok := bareResponseBody(responseItem.Value, schemaExamples, opts); ok {
+					noContent.Headers = &[]Header{{Name: "Content-Type", Value: bodyContentType(body)}}
+					noContent.Body = &body
+				}
+				responses = append(responses, noContent)
+			}
+		}
+
+		// Add the alternate bodies of the code
+		if responseItem.Value != nil {
+			responses = append(responses, variantResponses(response, code, responseItem.Value, opts)...)
+		}
+
+		// Set the latency, the padding and the links of the responses of the key
+		if responseItem.Value != nil {
+			minDelay, maxDelay, err := responseDelay(responseItem.Value)
+			if err != nil {
+				warnf("%v, delay of response %s skipped", err, response)
+			}
+			padBytes, err := responsePadBytes(responseItem.Value)
+			if err != nil {
+				warnf("%v, padding of response %s skipped", err, response)
+			}
+			fixture, err := responseFixture(responseItem.Value)
+			if err != nil {
+				warnf("%v, fixture of response %s skipped", err, response)
+			}
+			links := responseLinks(responseItem.Value)
+			wildcard := ""
+			if _, err := strconv.Atoi(response); err != nil {
+				wildcard = response
+			}
+			for i := first; i < len(responses); i++ {
+				responses[i].Key = wildcard
+				responses[i].DelayMinMs, responses[i].DelayMaxMs = minDelay, maxDelay
+				responses[i].PadBytes = padBytes
+				responses[i].Fixture = fixture
+				responses[i].Links = links
 			}
 		}
 	}
 	return responses
 }
 
+// schemaBodyString returns the example body generated for a response schema.
+// Component schemas are looked up in the precomputed examples, inline object
+// and array schemas are generated on the fly.
+func schemaBodyString(schema *openapi3.SchemaRef, schemaExamples map[string]string, opts Options) string {
+	if schema.Ref != "" {
+		if bodyStr, ok := schemaExamples[schema.Ref]; ok {
+			return bodyStr
+		}
+	}
+	if schema.Value == nil || !(schema.Value.Type.Is("object") || len(schema.Value.Properties) > 0 || schemaType(schema.Value) == "array") {
+		return ""
+	}
+	return extractSchemaExample(schema.Value, opts)
+}
+
+// addCreatedBodies gives the 201 responses of a POST operation that have no
+// body the sample of its request body, as the created resource is usually
+// echoed back. Responses without content get the content type of the sample.
+func addCreatedBodies(responses []Response, operation *openapi3.Operation, schemaExamples map[string]string, opts Options) {
+	for i, response := range responses {
+		if response.Body != nil || response.Code != 201 {
+			continue
+		}
+		body, contentType, ok := requestBodySample(operation, schemaExamples, opts)
+		if !ok {
+			return
+		}
+		if response.ContentType() == "" {
+			responses[i].Headers = &[]Header{{Name: "Content-Type", Value: contentType}}
+		}
+		responses[i].Body = &body
+	}
+}
+
+// addDefaultBodies gives the configured default body of their content type to
+// the success responses that have none. The "*" content type matches any
+// content type; responses without content are left empty.
+func addDefaultBodies(responses []Response, defaultBodies map[string]string) {
+	for i, response := range responses {
+		if response.Body != nil || response.Code < 200 || response.Code > 299 {
+			continue
+		}
+		contentType := response.ContentType()
+		if contentType == "" {
+			continue
+		}
+		if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+			contentType = mediaType
+		}
+		body, ok := defaultBodies["*"]
+		for bodyType, defaultBody := range defaultBodies {
+			if strings.EqualFold(bodyType, contentType) {
+				body, ok = defaultBody, true
+			}
+		}
+		if ok {
+			responses[i].Body = &body
+		}
+	}
+}
+
+// emptyExamples reports whether none of the named examples has a value.
+func emptyExamples(examples openapi3.Examples) bool {
+	for _, example := range examples {
+		if getBodyString(example) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// responseCode returns the status code of a response key. Range keys like 4XX
+// stand for their first code, e.g. 400. The default response stands for the
+// default code when given, else for 200 when the operation declares no success
+// response, and for 500 otherwise.
+func responseCode(key string, keys []string, defaultCode int) (int, error) {
+	if code, err := strconv.Atoi(key); err == nil {
+		return code, nil
+	}
+	if len(key) == 3 && strings.EqualFold(key[1:], "XX") && key[0] >= '1' && key[0] <= '5' {
+		return int(key[0]-'0') * 100, nil
+	}
+	if key == "default" {
+		if defaultCode != 0 {
+			return defaultCode, nil
+		}
+		for _, other := range keys {
+			if other != key && strings.HasPrefix(other, "2") {
+				return 500, nil
+			}
+		}
+		return 200, nil
+	}
+	return 0, fmt.Errorf("invalid response code %q", key)
+}
+
 func getBodyString(exampleRef *openapi3.ExampleRef) string {
 	if exampleRef == nil || exampleRef.Value == nil {
 		return ""
 	}
-	examapleObject := exampleRef.Value.Value
+	return exampleBodyString(exampleRef.Value.Value)
+}
+
+// exampleBodyString converts an example value to a response body.
+func exampleBodyString(examapleObject interface{}) string {
 	if examapleObject == nil {
 		return ""
 	}
@@ -238,31 +926,253 @@ func getBodyString(exampleRef *openapi3.ExampleRef) string {
 	return bodyStr
 }
 
-func extractSchemaExample(schema *openapi3.Schema) string {
-	om := NewOrderedMap()
+// reparseStringExample parses an example body of a JSON or YAML content type
+// holding a stringified JSON or YAML document, and returns it as indented JSON.
+// Other bodies, e.g. plain text, are returned unchanged.
+func reparseStringExample(body, contentType string) string {
+	if !isJSONContentType(contentType) && !isYAMLContentType(contentType) {
+		return body
+	}
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(body), &document); err != nil || len(document.Content) == 0 {
+		return body
+	}
+	if kind := document.Content[0].Kind; kind != yaml.MappingNode && kind != yaml.SequenceNode {
+		return body
+	}
+	value, err := nodeValue(&document)
+	if err != nil {
+		return body
+	}
+	jsonData, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return body
+	}
+	return string(jsonData)
+}
 
-	schemaType := schema.Type
-	if schemaType.Is("object") {
-		// Extract the properties
-		if schema.Properties != nil {
-			for propName, propSchema := range schema.Properties {
-				childSchema := propSchema.Value
-				childSchemaType := childSchema.Type
-				if childSchemaType.Is("string") || childSchemaType.Is("integer") {
-					om.Set(propName, childSchema.Example)
-				}
-			}
-		}
+func extractSchemaExample(schema *openapi3.Schema, opts Options) string {
+	var example interface{}
+	if value, ok := firstSchemaExample(schema); ok {
+		// The examples of the schema are used before synthesizing the fields
+		example = value
+	} else if schemaType(schema) == "object" {
+		example = objectExample(schema, opts)
+	} else if value, ok := propertyExample("", schema, opts); ok && value != nil {
+		// Arrays and the other schemas are generated like properties
+		example = value
+	} else {
+		example = NewOrderedMap()
 	}
 
 	// Marshal the schema to JSON
-	finalData, err := json.MarshalIndent(om, "", "  ")
+	finalData, err := json.MarshalIndent(example, "", "  ")
 	if err != nil {
 		return ""
 	}
 	return string(finalData)
 }
 
+// objectExample generates the example of an object schema from its properties.
+// The properties are generated in the order of their names, as the maps of the
+// examples are marshaled, so that the bodies are stable from run to run.
+func objectExample(schema *openapi3.Schema, opts Options) *OrderedMap {
+	om := NewOrderedMap()
+	// Extract the properties
+	propNames := make([]string, 0, len(schema.Properties))
+	for propName := range schema.Properties {
+		propNames = append(propNames, propName)
+	}
+	sort.Strings(propNames)
+	for _, propName := range propNames {
+		propSchema := schema.Properties[propName]
+		if propSchema == nil || propSchema.Value == nil {
+			continue
+		}
+		if value, ok := propertyExample(propName, propSchema.Value, opts); ok {
+			om.Set(propName, value)
+		}
+	}
+	return om
+}
+
+// propertyExample generates the example of a property, or of an array item. The
+// second return value is false when no value is generated for the schema.
+func propertyExample(propName string, schema *openapi3.Schema, opts Options) (interface{}, bool) {
+	if value, ok := constExample(schema); ok {
+		return value, true
+	}
+	if value, ok := firstSchemaExample(schema); ok && schema.Example == nil {
+		return value, true
+	}
+	if value, ok := fakerExample(propName, schema, opts); ok {
+		return value, true
+	}
+	if schema.Example == nil && len(schema.Enum) > 0 {
+		return enumExample(schema.Enum, opts), true
+	}
+	switch schemaType(schema) {
+	case "string":
+		if value, ok := formatExample(schema.Format); ok && schema.Example == nil {
+			return value, true
+		}
+		return schema.Example, true
+	case "integer":
+		return schema.Example, true
+	case "null":
+		return nil, true
+	case "array":
+		if schema.Example != nil {
+			return schema.Example, true
+		}
+		return arrayExample(propName, schema, opts), true
+	case "object":
+		if schema.Example != nil {
+			return schema.Example, true
+		}
+		return objectExample(schema, opts), true
+	}
+
+	// Without a value to generate, null is used when the schema permits it
+	if schema.Example == nil && isNullable(schema) {
+		return nil, true
+	}
+	return nil, false
+}
+
+// arrayExample generates the example of an array schema. Tuples get one element
+// per prefixItems schema, in order, before the elements generated from items,
+// which are added up to minItems, or once for arrays without prefixItems.
+func arrayExample(propName string, schema *openapi3.Schema, opts Options) []interface{} {
+	items := []interface{}{}
+	for _, itemSchema := range prefixItemSchemas(schema) {
+		value, _ := propertyExample(propName, itemSchema, opts)
+		items = append(items, value)
+	}
+
+	if schema.Items == nil || schema.Items.Value == nil {
+		return items
+	}
+	count := max(int(schema.MinItems), len(items))
+	if len(items) == 0 {
+		count = max(count, 1)
+	}
+	for len(items) < count {
+		value, _ := propertyExample(propName, schema.Items.Value, opts)
+		items = append(items, value)
+	}
+	return items
+}
+
+// prefixItemSchemas returns the positional schemas of an OpenAPI 3.1 tuple. The
+// loader keeps the prefixItems keyword unparsed among the schema extensions.
+func prefixItemSchemas(schema *openapi3.Schema) []*openapi3.Schema {
+	rawItems, ok := schema.Extensions["prefixItems"].([]interface{})
+	if !ok {
+		return nil
+	}
+	schemas := []*openapi3.Schema{}
+	for _, rawItem := range rawItems {
+		data, err := json.Marshal(rawItem)
+		if err != nil {
+			continue
+		}
+		itemSchema := &openapi3.Schema{}
+		if err := itemSchema.UnmarshalJSON(data); err != nil {
+			warnf("invalid prefixItems schema %s: %v", data, err)
+			continue
+		}
+		schemas = append(schemas, itemSchema)
+	}
+	return schemas
+}
+
+// schemaType returns the type to generate for a schema. OpenAPI 3.1 allows a list
+// of types such as [string, null]: the first non-null type is used, and "null"
+// only when it is the sole type.
+func schemaType(schema *openapi3.Schema) string {
+	if schema.Type == nil {
+		return ""
+	}
+	for _, typ := range schema.Type.Slice() {
+		if typ != openapi3.TypeNull {
+			return typ
+		}
+	}
+	if isNullable(schema) {
+		return openapi3.TypeNull
+	}
+	return ""
+}
+
+// isNullable reports whether a schema permits null, either with the nullable
+// keyword of OpenAPI 3.0 or with a null type as in OpenAPI 3.1.
+func isNullable(schema *openapi3.Schema) bool {
+	return schema.Nullable || schema.Type != nil && schema.Type.Includes(openapi3.TypeNull)
+}
+
+// formatExample returns a placeholder valid for the format of a string schema
+// without example: base64 content for byte, a masked value for password.
+func formatExample(format string) (string, bool) {
+	switch format {
+	case "byte":
+		return "ZXhhbXBsZQ==", true // base64 of "example"
+	case "password":
+		return "********", true
+	}
+	return "", false
+}
+
+// constExample returns the `const` value of a schema, which fixes the property to a
+// single value. The loader keeps the keyword among the schema extensions.
+func constExample(schema *openapi3.Schema) (interface{}, bool) {
+	value, ok := schema.Extensions["const"]
+	return value, ok
+}
+
+// firstSchemaExample returns the first entry of the `examples` array of a schema,
+// as in OpenAPI 3.1. The loader keeps the keyword among the schema extensions.
+func firstSchemaExample(schema *openapi3.Schema) (interface{}, bool) {
+	examples, ok := schema.Extensions["examples"].([]interface{})
+	if !ok || len(examples) == 0 {
+		return nil, false
+	}
+	return examples[0], true
+}
+
+// enumExample picks the value of an enum according to the enum strategy:
+//   - "first" (default): the first value
+//   - "last": the last value
+//   - "random": a random value, reproducible with a seed
+//   - "index:N": the value at index N, the last one when out of range
+func enumExample(enum []interface{}, opts Options) interface{} {
+	switch strategy := opts.EnumStrategy; {
+	case strategy == EnumLast:
+		return enum[len(enum)-1]
+	case strategy == EnumRandom:
+		return enum[opts.rand().Intn(len(enum))]
+	case strings.HasPrefix(strategy, enumIndexPrefix):
+		index, _ := strconv.Atoi(strings.TrimPrefix(strategy, enumIndexPrefix))
+		return enum[min(index, len(enum)-1)]
+	}
+	return enum[0]
+}
+
+// fakerExample generates a value for a property annotated with the `x-faker` extension.
+// Unknown faker names are reported and the property falls back to the default logic.
+func fakerExample(propName string, schema *openapi3.Schema, opts Options) (interface{}, bool) {
+	name, ok := schema.Extensions["x-faker"].(string)
+	if !ok {
+		return nil, false
+	}
+	value, ok := fakeValue(name, opts.rand())
+	if !ok {
+		warnf("unknown x-faker %q on property %s, falling back to default example", name, propName)
+		return nil, false
+	}
+	return value, true
+}
+
 // cleanFolderName takes a string and returns a valid folder name by first trimming
 // leading and trailing spaces, replacing internal spaces with underscores, and
 // removing characters that are not allowed in folder names.
@@ -287,82 +1197,496 @@ func cleanFolderName(name string) string {
 	return cleanName
 }
 
-func (m *MockServerSetting) CreateFolder(targetFolder string) {
+// requestFolderName returns the folder name used for the files of a request.
+// It is the cleaned operation id, or a name derived from the path when the
+// operation has no id.
+func requestFolderName(request Request) string {
+	if name := cleanFolderName(request.Name); name != "" {
+		return name
+	}
+	return pathFolderName(request.Path)
+}
+
+// pathFolderName normalizes a request path into a folder name. Template braces and
+// characters with a special meaning in file systems or regular expressions (such as
+// '.', '+' or ':') are replaced with underscores, e.g. "/files/{name}.{ext}" becomes
+// "files_name_ext".
+func pathFolderName(path string) string {
+	var builder strings.Builder
+	underscore := false
+	for _, r := range path {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-') {
+			builder.WriteRune(r)
+			underscore = false
+		} else if !underscore {
+			builder.WriteRune('_')
+			underscore = true
+		}
+	}
+	name := strings.Trim(builder.String(), "_")
+	if name == "" {
+		return "root"
+	}
+	return name
+}
+
+func (m *MockServerSetting) CreateFolder(targetFolder string) error {
 	// Clean the folder name
-	folderName := cleanFolderName(m.Name)
+	folderName := m.folderName()
+	m.FolderName = folderName
 
 	// Trim right slash
 	targetFolder = strings.TrimRight(targetFolder, "/")
 	targetFolder = strings.TrimRight(targetFolder, "\\")
 
-	// Set the folder path for the mock server
-	m.Folder = fmt.Sprintf("%s/data/%s", targetFolder, folderName)
+	// Set the folder path for the mock server, under the data folder if any
+	m.TargetFolder = targetFolder
+	m.Folder = fmt.Sprintf("%s/%s", targetFolder, folderName)
+	if dataDir := m.Options.dataDir(); dataDir != "" {
+		m.Folder = fmt.Sprintf("%s/%s/%s", targetFolder, dataDir, folderName)
+	}
 
 	// Create the data folder if it does not exist
-	if _, err := os.Stat(m.Folder); os.IsNotExist(err) {
-		if err := os.Mkdir(m.Folder, 0755); err != nil {
-			log.Fatalf("Failed to create data folder: %v", err)
-		}
+	if err := ensureFolder(m.Folder, m.Options.dirMode()); err != nil {
+		return fmt.Errorf("failed to create data folder: %w", err)
 	}
+	return nil
+}
+
+// makeFolder creates a folder along with its parents. It is a variable so tests
+// can simulate concurrent generations.
+var makeFolder = os.MkdirAll
+
+// ensureFolder creates a folder if it does not exist. A folder created
+// concurrently by another generation is not an error.
+func ensureFolder(path string, mode os.FileMode) error {
+	if err := makeFolder(path, mode); err != nil && !os.IsExist(err) {
+		return err
+	}
+	return nil
 }
 
 // SaveSetting saves the mock server setting to a file.
 // Save response files for each request
-func (m *MockServerSetting) SaveSetting() {
+func (m *MockServerSetting) SaveSetting() error {
+	// Pick a port no other mock server of the target folder uses
+	if m.Options.BasePort != 0 && m.Options.Port == 0 {
+		if err := m.assignBasePort(); err != nil {
+			return err
+		}
+	}
+
+	// Compare with the previous generation before its files are overwritten
+	if m.Options.Diff != "" {
+		previous, err := m.loadPreviousSnapshot()
+		if err != nil {
+			return err
+		}
+		if err := m.writeDiff(previous); err != nil {
+			return err
+		}
+	}
+
+	saveBodies := m.saveBodyFiles
+	if m.Options.SingleFile {
+		saveBodies = m.saveBodiesFile
+	}
+	if err := saveBodies(); err != nil {
+		return err
+	}
+
+	if m.Options.EmitCacheHeaders {
+		m.addCacheHeaders(m.Options.specModTime)
+	}
+
+	if m.Options.EmitRequestExamples {
+		if err := m.saveRequestExamples(); err != nil {
+			return err
+		}
+	}
+
+	if m.Options.EmitSchemas {
+		if err := m.saveSchemasFile(); err != nil {
+			return err
+		}
+	}
+
+	if m.Options.EmitErrors {
+		if err := m.saveErrorCatalog(); err != nil {
+			return err
+		}
+	}
+
+	if m.Options.Report {
+		if err := m.saveRefsReport(); err != nil {
+			return err
+		}
+	}
+
+	if m.Options.EmitFragments {
+		if err := m.saveFragments(); err != nil {
+			return err
+		}
+	}
+
+	if m.Options.Index {
+		if err := m.saveIndexFile(); err != nil {
+			return err
+		}
+	}
+
+	if m.Options.Export != "" {
+		if err := m.saveExport(); err != nil {
+			return err
+		}
+	}
+
+	// Create the setting file
+	settingFilePath := m.settingFilePath()
+
+	// Load the existing setting before the file is truncated
+	var existing *yaml.Node
+	if m.Options.Merge {
+		var err error
+		if existing, err = loadExistingSetting(settingFilePath); err != nil {
+			return err
+		}
+	}
+
+	file, err := os.OpenFile(settingFilePath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, m.Options.fileMode())
+	if err != nil {
+		return fmt.Errorf("failed to create mock server setting file: %w", err)
+	}
+	defer file.Close()
+
+	var document interface{} = m
+	if existing != nil || m.Options.YAMLAnchors {
+		var node yaml.Node
+		if err := node.Encode(m); err != nil {
+			return fmt.Errorf("failed to marshal mock server setting: %w", err)
+		}
+		if existing != nil {
+			mergeSetting(existing, &node, m.Options.Prune)
+		}
+		document = &node
+	}
+
+	if strings.EqualFold(filepath.Ext(settingFilePath), ".json") {
+		// Marshal the mock server setting to JSON format
+		if node, ok := document.(*yaml.Node); ok {
+			if document, err = nodeValue(node); err != nil {
+				return fmt.Errorf("failed to marshal mock server setting: %w", err)
+			}
+		}
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(document); err != nil {
+			return fmt.Errorf("failed to write mock server setting to file: %w", err)
+		}
+	} else {
+		// Marshal the mock server setting to YAML format
+		if node, ok := document.(*yaml.Node); ok && m.Options.YAMLAnchors {
+			anchorRepeatedHeaders(node)
+		}
+		indent := m.Options.YAMLIndent
+		if indent == 0 {
+			indent = 2 // Indent by 2 spaces by default
+		}
+		encoder := yaml.NewEncoder(file)
+		encoder.SetIndent(indent)
+		if err := encoder.Encode(document); err != nil {
+			return fmt.Errorf("failed to write mock server setting to file: %w", err)
+		}
+	}
+
+	infof("Mock server setting is saved to %s", settingFilePath)
+	return nil
+}
+
+// settingFilePath returns the path of the setting file in the mock server
+// folder.
+func (m *MockServerSetting) settingFilePath() string {
+	settingName := m.Options.SettingName
+	if settingName == "" {
+		settingName = "setting.yaml"
+	}
+	return fmt.Sprintf("%s/%s", m.Folder, settingName)
+}
+
+// saveBodyFiles saves the body of each response to its own file, under a
+// <method>/<name>/<code> folder, or directly in the mock server folder with a
+// <method>_<name>_<code> name when flattened. The responses sharing a name,
+// e.g. the named examples of a code, get numbered files.
+func (m *MockServerSetting) saveBodyFiles() error {
+	fileNames := map[string]bool{}
+	savedFixtures := map[string]bool{}
+
 	// Create folder for each response
 	for i, request := range m.Requests {
 		for j, response := range request.Responses {
-			folderRelativePath := fmt.Sprintf("%s/%s/%d", request.Method, request.Name, response.Code)
-			folderFullPath := fmt.Sprintf("%s/%s", m.Folder, folderRelativePath)
-			fileName := cleanFolderName(response.Name)
-			fileRelativePath := fmt.Sprintf("./data/%s/%s/%s.json", cleanFolderName(m.Name), folderRelativePath, fileName)
-			fileFullPath := fmt.Sprintf("%s/%s.json", folderFullPath, fileName)
-
-			if response.Body != nil {
-				// Save the folder path to the response
-				response.FilePath = &fileRelativePath
-				m.Requests[i].Responses[j] = response
-
-				// Create a folder for the response
-				if _, err := os.Stat(folderFullPath); os.IsNotExist(err) {
-					if err := os.MkdirAll(folderFullPath, 0755); err != nil {
-						log.Fatalf("Failed to create response folder: %v", err)
-					}
+			if response.Body == nil {
+				continue
+			}
+			extension := fileExtension(response.ContentType(), m.Options.Extensions)
+			var fileRelativePath string
+			if response.Fixture != "" {
+				fileRelativePath = fmt.Sprintf("%s/%s%s", fixturesFolderName, cleanFolderName(response.Fixture), extension)
+			} else if m.Options.Flatten {
+				fileRelativePath = uniqueFileName(fmt.Sprintf("%s_%s_%d", request.Method, requestFolderName(request), response.Code), extension, fileNames)
+			} else {
+				fileRelativePath = uniqueFileName(fmt.Sprintf("%s/%s/%d/%s", request.Method, requestFolderName(request), response.Code, cleanFolderName(response.Name)), extension, fileNames)
+			}
+			fileFullPath := fmt.Sprintf("%s/%s", m.Folder, fileRelativePath)
+
+			recordedPath, err := m.recordedPath(fileRelativePath)
+			if err != nil {
+				return err
+			}
+
+			// Save the folder path to the response
+			response.FilePath = &recordedPath
+			m.Requests[i].Responses[j] = response
+
+			// A fixture is written once for all the responses sharing it
+			if response.Fixture != "" {
+				if savedFixtures[fileRelativePath] {
+					continue
 				}
+				savedFixtures[fileRelativePath] = true
+			}
+
+			// Create a folder for the response
+			if err := ensureFolder(filepath.Dir(fileFullPath), m.Options.dirMode()); err != nil {
+				return fmt.Errorf("failed to create response folder: %w", err)
+			}
+
+			// Save the response body to a file
+			body := *response.Body
+			if m.Options.TrailingNewline {
+				body = strings.TrimRight(body, "\n") + "\n"
+			}
+			if err := os.WriteFile(fileFullPath, []byte(body), m.Options.fileMode()); err != nil {
+				return fmt.Errorf("failed to write response body to file: %w", err)
+			}
+			infof("Response body is saved to %s", *response.FilePath)
+			debugf("Response body preview: %s", bodyPreview(*response.Body, bodyPreviewLength))
+		}
+	}
+	return nil
+}
+
+// uniqueFileName returns the file name of a body, suffixed with a counter when
+// the name is already used, e.g. GET_getPet_200_2.json.
+func uniqueFileName(name string, extension string, used map[string]bool) string {
+	fileName := name + extension
+	for n := 2; used[strings.ToLower(fileName)]; n++ {
+		fileName = fmt.Sprintf("%s_%d%s", name, n, extension)
+	}
+	used[strings.ToLower(fileName)] = true
+	return fileName
+}
 
-				// Save the response body to a file
-				if err := os.WriteFile(fileFullPath, []byte(*response.Body), 0644); err != nil {
-					log.Fatalf("Failed to write response body to file: %v", err)
+// recordedPath returns the path recorded in the setting for a file of the mock
+// server, given relative to its folder. The form of the path depends on the
+// path style option:
+//   - "target" (default): relative to the target folder, e.g. ./data/<name>/<file>,
+//     without the data folder when there is none
+//   - "relative": relative to the setting file, e.g. ./<file>
+//   - "absolute": absolute path of the file
+func (m *MockServerSetting) recordedPath(relativePath string) (string, error) {
+	switch m.Options.PathStyle {
+	case PathStyleRelative:
+		return "./" + relativePath, nil
+	case PathStyleAbsolute:
+		absolutePath, err := filepath.Abs(filepath.Join(m.Folder, relativePath))
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve absolute path: %w", err)
+		}
+		return absolutePath, nil
+	default:
+		return m.targetPathPrefix() + relativePath, nil
+	}
+}
+
+// targetPathPrefix returns the prefix of the paths of the files of the mock
+// server relative to the target folder, e.g. ./data/<name>/.
+func (m *MockServerSetting) targetPathPrefix() string {
+	if dataDir := m.Options.dataDir(); dataDir != "" {
+		return fmt.Sprintf("./%s/%s/", dataDir, m.FolderName)
+	}
+	return fmt.Sprintf("./%s/", m.FolderName)
+}
+
+// saveBodiesFile saves the bodies of all responses into a single bodies.json document,
+// keyed by "METHOD path CODE name". The FilePath of each response is set to a JSON
+// pointer into that document.
+func (m *MockServerSetting) saveBodiesFile() error {
+	bodies := NewOrderedMap()
+	fileRelativePath, err := m.recordedPath("bodies.json")
+	if err != nil {
+		return err
+	}
+	for i, request := range m.Requests {
+		for j, response := range request.Responses {
+			if response.Body == nil {
+				continue
+			}
+			key := fmt.Sprintf("%s %s %d %s", request.Method, request.Path, response.Code, cleanFolderName(response.Name))
+			if bodies.Has(key) {
+				// Several examples may share the same code and name
+				for n := 2; ; n++ {
+					if candidate := fmt.Sprintf("%s_%d", key, n); !bodies.Has(candidate) {
+						key = candidate
+						break
+					}
 				}
-				log.Printf("Response body is saved to %s\n", *response.FilePath)
 			}
+
+			// Keep JSON bodies as JSON, anything else is stored as a string
+			if json.Valid([]byte(*response.Body)) {
+				bodies.Set(key, json.RawMessage(*response.Body))
+			} else {
+				bodies.Set(key, *response.Body)
+			}
+
+			filePath := fileRelativePath + "#/" + escapeJSONPointer(key)
+			m.Requests[i].Responses[j].FilePath = &filePath
+			debugf("Response body preview of %s: %s", key, bodyPreview(*response.Body, bodyPreviewLength))
 		}
 	}
 
-	// Create the setting file
-	settingFilePath := fmt.Sprintf("%s/setting.yaml", m.Folder)
-	file, err := os.Create(settingFilePath)
+	data, err := json.MarshalIndent(bodies, "", "  ")
 	if err != nil {
-		log.Fatalf("Failed to create mock server setting file: %v", err)
+		return fmt.Errorf("failed to marshal response bodies: %w", err)
 	}
-	defer file.Close()
+	bodiesFilePath := fmt.Sprintf("%s/bodies.json", m.Folder)
+	if err := os.WriteFile(bodiesFilePath, data, m.Options.fileMode()); err != nil {
+		return fmt.Errorf("failed to write response bodies to file: %w", err)
+	}
+	infof("Response bodies are saved to %s", fileRelativePath)
+	return nil
+}
+
+// saveSchemasFile saves the generated example of each component schema into
+// schemas.json, keyed by the schema reference.
+func (m *MockServerSetting) saveSchemasFile() error {
+	refs := make([]string, 0, len(m.Schemas))
+	for ref := range m.Schemas {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+
+	schemas := NewOrderedMap()
+	for _, ref := range refs {
+		schemas.Set(ref, schemaExampleValue(m.Schemas[ref]))
+	}
+	data, err := json.MarshalIndent(schemas, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal schema examples: %w", err)
+	}
+	schemasFilePath := fmt.Sprintf("%s/schemas.json", m.Folder)
+	if err := os.WriteFile(schemasFilePath, data, m.Options.fileMode()); err != nil {
+		return fmt.Errorf("failed to write schema examples to file: %w", err)
+	}
+	infof("Schema examples are saved to %s", schemasFilePath)
+	return nil
+}
+
+// schemaExampleValue returns the value of a schema example in schemas.json. An
+// example that is not valid JSON is kept as a string.
+func schemaExampleValue(example string) interface{} {
+	if json.Valid([]byte(example)) {
+		return json.RawMessage(example)
+	}
+	return example
+}
 
-	// Marshal the mock server setting to YAML format
-	encoder := yaml.NewEncoder(file)
-	encoder.SetIndent(2) // Indent by 2 spaces
-	if encoder.Encode(m) != nil {
-		log.Fatalf("Failed to write mock server setting to file: %v", err)
+// SaveSchemaExamples writes the example of each component schema into
+// schemas.json of the target folder, keyed by schema name. Used by the
+// schemas-only mode, which generates no mock server.
+func SaveSchemaExamples(openAPISpec openapi3.T, targetFolder string, opts Options) error {
+	opts.random = newRandom(conversionSeed(opts, openAPISpec))
+	schemaExamples := getSchemaExamples(openAPISpec, opts)
+	names := make([]string, 0, len(schemaExamples))
+	for ref := range schemaExamples {
+		names = append(names, strings.TrimPrefix(ref, "#/components/schemas/"))
 	}
+	sort.Strings(names)
+
+	schemas := NewOrderedMap()
+	for _, name := range names {
+		schemas.Set(name, schemaExampleValue(schemaExamples["#/components/schemas/"+name]))
+	}
+	data, err := json.MarshalIndent(schemas, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal schema examples: %w", err)
+	}
+	schemasFilePath := filepath.Join(targetFolder, "schemas.json")
+	if err := os.WriteFile(schemasFilePath, data, opts.fileMode()); err != nil {
+		return fmt.Errorf("failed to write schema examples to file: %w", err)
+	}
+	infof("Schema examples are saved to %s", schemasFilePath)
+	return nil
+}
 
-	fmt.Printf("Mock server setting is saved to %s\n", settingFilePath)
+// escapeJSONPointer escapes a reference token as described in RFC 6901.
+func escapeJSONPointer(token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	return strings.ReplaceAll(token, "/", "~1")
 }
 
-func (m *MockServerSetting) CopyOpenAPIFile(openApiFile string) {
-	data, _ := os.ReadFile(openApiFile)
+// Summary returns a one-line summary of the generated requests, responses and
+// body files.
+func (m *MockServerSetting) Summary() string {
+	responses := 0
+	files := map[string]bool{}
+	for _, request := range m.Requests {
+		responses += len(request.Responses)
+		for _, response := range request.Responses {
+			if response.FilePath != nil {
+				// Bodies saved in a single file are pointers into it
+				file, _, _ := strings.Cut(*response.FilePath, "#")
+				files[file] = true
+			}
+		}
+	}
+	return fmt.Sprintf("Generated %d requests, %d responses, %d body files", len(m.Requests), responses, len(files))
+}
+
+func (m *MockServerSetting) CopyOpenAPIFile(openApiFile string) error {
+	data, err := os.ReadFile(openApiFile)
+	if err != nil {
+		return fmt.Errorf("failed to read OpenAPI file: %w", err)
+	}
 	filePath := m.Folder + "/openapi" + filepath.Ext(openApiFile)
-	if err := os.WriteFile(filePath, data, 0644); err != nil {
-		log.Fatalf("Failed to copy OpenAPI file to data folder: %v", err)
+	if err := os.WriteFile(filePath, data, m.Options.fileMode()); err != nil {
+		return fmt.Errorf("failed to copy OpenAPI file to data folder: %w", err)
+	}
+	infof("OpenAPI file copied to data folder: %s", filePath)
+	return nil
+}
+
+// RunPostHook runs the given shell command once the mock server is generated.
+// The folder, host and port of the mock server are passed to the command as
+// MOCK_TARGET_FOLDER, MOCK_HOST and MOCK_PORT environment variables.
+func (m *MockServerSetting) RunPostHook(command string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
 	} else {
-		log.Printf("OpenAPI file copied to data folder: %s", filePath)
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(),
+		"MOCK_TARGET_FOLDER="+m.Folder,
+		"MOCK_HOST="+m.Host,
+		"MOCK_PORT="+strconv.Itoa(m.Port),
+	)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	infof("Running post-generation hook: %s", command)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", command, err)
 	}
+	return nil
 }
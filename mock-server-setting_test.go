@@ -0,0 +1,2318 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
+)
+
+// loadTestSpec parses an inline OpenAPI document for tests.
+func loadTestSpec(t *testing.T, spec string) openapi3.T {
+	t.Helper()
+	loader := openapi3.NewLoader()
+	doc, err := loader.LoadFromData([]byte(spec))
+	if err != nil {
+		t.Fatalf("Failed to load test spec: %v", err)
+	}
+	return *doc
+}
+
+func TestExtractSchemaExampleWithFaker(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Faker API
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    User:
+      type: object
+      properties:
+        email:
+          type: string
+          example: fixed@example.com
+          x-faker: internet.email
+        nickname:
+          type: string
+          example: nick
+          x-faker: unknown.faker
+`)
+	body := extractSchemaExample(spec.Components.Schemas["User"].Value, Options{})
+
+	var user map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &user); err != nil {
+		t.Fatalf("Invalid example JSON %q: %v", body, err)
+	}
+	email, _ := user["email"].(string)
+	if email == "fixed@example.com" || !strings.Contains(email, "@") {
+		t.Errorf("expect email generated by x-faker, got %q", email)
+	}
+	if user["nickname"] != "nick" {
+		t.Errorf("expect unknown faker to fall back to example, got %v", user["nickname"])
+	}
+}
+
+func TestExtractSchemaExampleFormats(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Format API
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    Account:
+      type: object
+      properties:
+        avatar:
+          type: string
+          format: byte
+        password:
+          type: string
+          format: password
+        secret:
+          type: string
+          format: password
+          example: hunter2
+`)
+	body := extractSchemaExample(spec.Components.Schemas["Account"].Value, Options{})
+
+	var account map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &account); err != nil {
+		t.Fatalf("Invalid example JSON %q: %v", body, err)
+	}
+	avatar, _ := account["avatar"].(string)
+	if _, err := base64.StdEncoding.DecodeString(avatar); err != nil || avatar == "" {
+		t.Errorf("expect base64 placeholder for format byte, got %q", avatar)
+	}
+	if account["password"] != "********" {
+		t.Errorf("expect masked placeholder for format password, got %v", account["password"])
+	}
+	if account["secret"] != "hunter2" {
+		t.Errorf("expect the example to win over the format placeholder, got %v", account["secret"])
+	}
+}
+
+func TestExtractSchemaExampleNullable(t *testing.T) {
+	bodies := map[string]string{}
+	for version, score := range map[string]string{
+		"3.0.0": "type: number\n          nullable: true",
+		"3.1.0": "type: [number, \"null\"]",
+	} {
+		spec := loadTestSpec(t, `
+openapi: "`+version+`"
+info:
+  title: Nullable API
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    Player:
+      type: object
+      properties:
+        score:
+          `+score+`
+        rank:
+          type: number
+`)
+		bodies[version] = extractSchemaExample(spec.Components.Schemas["Player"].Value, Options{})
+	}
+
+	if bodies["3.0.0"] != "{\n  \"score\": null\n}" {
+		t.Errorf("expect null for the nullable field only, got %s", bodies["3.0.0"])
+	}
+	if bodies["3.1.0"] != bodies["3.0.0"] {
+		t.Errorf("expect a null type to behave as nullable, got %s and %s", bodies["3.1.0"], bodies["3.0.0"])
+	}
+}
+
+func TestExtractSchemaExampleStableOrder(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Order API
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    Item:
+      type: object
+      properties:
+        zeta:
+          type: string
+          example: z
+        alpha:
+          type: string
+          example: a
+        labels:
+          type: object
+          example: {"z": 1, "m": 2, "a": 3}
+        mid:
+          type: integer
+          example: 1
+`)
+	expected := `{
+  "alpha": "a",
+  "labels": {
+    "a": 3,
+    "m": 2,
+    "z": 1
+  },
+  "mid": 1,
+  "zeta": "z"
+}`
+	for i := 0; i < 10; i++ {
+		if body := extractSchemaExample(spec.Components.Schemas["Item"].Value, Options{}); body != expected {
+			t.Fatalf("expect keys in a stable sorted order, got %s", body)
+		}
+	}
+}
+
+func TestExtractSchemaExampleWithConst(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.1.0"
+info:
+  title: Const API
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    Cat:
+      type: object
+      properties:
+        kind:
+          type: string
+          const: cat
+          example: dog
+          x-faker: lorem.word
+        lives:
+          type: integer
+          const: 9
+`)
+	body := extractSchemaExample(spec.Components.Schemas["Cat"].Value, Options{})
+
+	var cat map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &cat); err != nil {
+		t.Fatalf("Invalid example JSON %q: %v", body, err)
+	}
+	if cat["kind"] != "cat" {
+		t.Errorf("expect const to take precedence, got %v", cat["kind"])
+	}
+	if cat["lives"] != float64(9) {
+		t.Errorf("expect const integer, got %v", cat["lives"])
+	}
+}
+
+func TestExtractSchemaExampleWithExamples(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.1.0"
+info:
+  title: Examples API
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    Cat:
+      type: object
+      examples:
+        - name: Tom
+          lives: 9
+        - name: Felix
+      properties:
+        name:
+          type: string
+          example: Garfield
+    Owner:
+      type: object
+      properties:
+        name:
+          type: string
+          examples: [Jon, Liz]
+`)
+	body := extractSchemaExample(spec.Components.Schemas["Cat"].Value, Options{})
+	var cat map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &cat); err != nil {
+		t.Fatalf("Invalid example JSON %q: %v", body, err)
+	}
+	if cat["name"] != "Tom" || cat["lives"] != float64(9) {
+		t.Errorf("expect the first example of the schema, got %v", cat)
+	}
+
+	body = extractSchemaExample(spec.Components.Schemas["Owner"].Value, Options{})
+	var owner map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &owner); err != nil {
+		t.Fatalf("Invalid example JSON %q: %v", body, err)
+	}
+	if owner["name"] != "Jon" {
+		t.Errorf("expect the first example of the property, got %v", owner["name"])
+	}
+}
+
+func TestExtractSchemaExampleWithTypeArray(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.1.0"
+info:
+  title: Type Array API
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    Pet:
+      type: [object]
+      properties:
+        name:
+          type: [string, "null"]
+          example: Tom
+        age:
+          type: ["null", integer]
+          example: 3
+        owner:
+          type: "null"
+`)
+	body := extractSchemaExample(spec.Components.Schemas["Pet"].Value, Options{})
+
+	var pet map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &pet); err != nil {
+		t.Fatalf("Invalid example JSON %q: %v", body, err)
+	}
+	if pet["name"] != "Tom" {
+		t.Errorf("expect string of [string, null] type, got %v", pet["name"])
+	}
+	if pet["age"] != float64(3) {
+		t.Errorf("expect integer of [null, integer] type, got %v", pet["age"])
+	}
+	if owner, ok := pet["owner"]; !ok || owner != nil {
+		t.Errorf("expect null owner, got %v (present: %v)", owner, ok)
+	}
+}
+
+func TestExtractSchemaExampleWithPrefixItems(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.1.0"
+info:
+  title: Tuple API
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    Point:
+      type: object
+      properties:
+        label:
+          type: array
+          prefixItems:
+            - type: string
+              example: origin
+            - type: integer
+              example: 42
+        tags:
+          type: array
+          minItems: 2
+          prefixItems:
+            - type: string
+              const: first
+          items:
+            type: string
+            example: extra
+        names:
+          type: array
+          items:
+            type: string
+            example: Tom
+`)
+	body := extractSchemaExample(spec.Components.Schemas["Point"].Value, Options{})
+
+	var point map[string][]interface{}
+	if err := json.Unmarshal([]byte(body), &point); err != nil {
+		t.Fatalf("Invalid example JSON %q: %v", body, err)
+	}
+	if fmt.Sprint(point["label"]) != "[origin 42]" {
+		t.Errorf("expect a [string, integer] tuple, got %v", point["label"])
+	}
+	if fmt.Sprint(point["tags"]) != "[first extra]" {
+		t.Errorf("expect prefixItems before items up to minItems, got %v", point["tags"])
+	}
+	if fmt.Sprint(point["names"]) != "[Tom]" {
+		t.Errorf("expect one element generated from items, got %v", point["names"])
+	}
+}
+
+func TestExtractSchemaExampleTopLevelArray(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Tags API
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    Tags:
+      type: array
+      items:
+        type: string
+        example: cat
+    Pets:
+      type: array
+      items:
+        $ref: '#/components/schemas/Pet'
+    Pet:
+      type: object
+      properties:
+        name:
+          type: string
+          example: Tom
+`)
+	examples := getSchemaExamples(spec, Options{})
+	for ref, expected := range map[string]string{
+		"#/components/schemas/Tags": `["cat"]`,
+		"#/components/schemas/Pets": `[{"name":"Tom"}]`,
+	} {
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, []byte(examples[ref])); err != nil || compact.String() != expected {
+			t.Errorf("expect %s for %s, got %q (%v)", expected, ref, examples[ref], err)
+		}
+	}
+}
+
+// generateTestMock converts an inline OpenAPI document and saves the mock server
+// into a temporary target folder.
+func generateTestMock(t *testing.T, spec string, opts Options) MockServerSetting {
+	t.Helper()
+	targetFolder := t.TempDir()
+	if err := os.Mkdir(filepath.Join(targetFolder, "data"), 0755); err != nil {
+		t.Fatalf("Failed to create data folder: %v", err)
+	}
+	setting := ConvertOpenAPIToMockServer(loadTestSpec(t, spec), opts)
+	if err := setting.CreateFolder(targetFolder); err != nil {
+		t.Fatalf("Failed to create folder: %v", err)
+	}
+	if err := setting.SaveSetting(); err != nil {
+		t.Fatalf("Failed to save setting: %v", err)
+	}
+	return setting
+}
+
+const petSpec = `
+openapi: "3.0.0"
+info:
+  title: Pet API
+  version: 1.0.0
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              examples:
+                cat:
+                  value: {"name": "Tom"}
+            text/plain:
+              examples:
+                cat:
+                  value: Tom
+        '404':
+          description: Not found
+`
+
+func TestSaveSettingSingleFile(t *testing.T) {
+	setting := generateTestMock(t, petSpec, Options{SingleFile: true})
+
+	data, err := os.ReadFile(filepath.Join(setting.Folder, "bodies.json"))
+	if err != nil {
+		t.Fatalf("Failed to read bodies.json: %v", err)
+	}
+	var bodies map[string]interface{}
+	if err := json.Unmarshal(data, &bodies); err != nil {
+		t.Fatalf("Invalid bodies.json: %v", err)
+	}
+	if len(bodies) != 2 {
+		t.Fatalf("expect 2 bodies, got %d: %s", len(bodies), data)
+	}
+
+	for _, response := range setting.Requests[0].Responses {
+		if response.Body == nil {
+			if response.FilePath != nil {
+				t.Errorf("expect no file path for response without body, got %s", *response.FilePath)
+			}
+			continue
+		}
+		prefix := "./data/Pet_API/bodies.json#/"
+		if response.FilePath == nil || !strings.HasPrefix(*response.FilePath, prefix) {
+			t.Fatalf("expect file path to point into bodies.json, got %v", response.FilePath)
+		}
+		key := strings.TrimPrefix(*response.FilePath, prefix)
+		key = strings.ReplaceAll(strings.ReplaceAll(key, "~1", "/"), "~0", "~")
+		value, ok := bodies[key]
+		if !ok {
+			t.Fatalf("pointer %s does not resolve in bodies.json", *response.FilePath)
+		}
+		switch value := value.(type) {
+		case string:
+			if value != *response.Body {
+				t.Errorf("expect body %q, got %q", *response.Body, value)
+			}
+		case map[string]interface{}:
+			if value["name"] != "Tom" {
+				t.Errorf("expect JSON body to be embedded, got %v", value)
+			}
+		default:
+			t.Errorf("unexpected body type %T", value)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(setting.Folder, "GET")); !os.IsNotExist(err) {
+		t.Errorf("expect no per-response folders in single file mode")
+	}
+}
+
+func TestSaveSettingPathWithSpecialCharacters(t *testing.T) {
+	setting := generateTestMock(t, `
+openapi: "3.0.0"
+info:
+  title: Files API
+  version: 1.0.0
+paths:
+  /files/{name}.{ext}:
+    get:
+      responses:
+        '200':
+          description: OK
+          content:
+            text/plain:
+              examples:
+                readme:
+                  value: hello
+  /files/archive.tar+gz:
+    get:
+      responses:
+        '200':
+          description: OK
+          content:
+            text/plain:
+              examples:
+                archive:
+                  value: archive
+`, Options{})
+
+	expected := map[string]string{
+		"/files/{name}.{ext}":   "files_name_ext",
+		"/files/archive.tar+gz": "files_archive_tar_gz",
+	}
+	for _, request := range setting.Requests {
+		folder := expected[request.Path]
+		if name := requestFolderName(request); name != folder {
+			t.Errorf("expect folder %q for path %s, got %q", folder, request.Path, name)
+		}
+		filePath := filepath.Join(setting.Folder, "GET", folder, "200", "OK.txt")
+		if _, err := os.Stat(filePath); err != nil {
+			t.Errorf("expect body file %s: %v", filePath, err)
+		}
+	}
+}
+
+func TestSaveSettingMultiLineDescription(t *testing.T) {
+	setting := generateTestMock(t, `
+openapi: "3.0.0"
+info:
+  title: Lines API
+  version: 1.0.0
+paths:
+  /lines:
+    get:
+      operationId: getLines
+      responses:
+        '200':
+          description: |-
+            Line one
+            line two
+          content:
+            text/plain:
+              example: hello
+`, Options{PathStyle: PathStyleRelative})
+
+	filePath := *setting.Requests[0].Responses[0].FilePath
+	if strings.Contains(filePath, "\n") {
+		t.Errorf("expect no new line in the body file path, got %q", filePath)
+	}
+	if expected := "./GET/getLines/200/Line_oneline_two.txt"; filePath != expected {
+		t.Errorf("expect body file path %q, got %q", expected, filePath)
+	}
+	if _, err := os.Stat(filepath.Join(setting.Folder, filePath)); err != nil {
+		t.Errorf("expect body file %s: %v", filePath, err)
+	}
+}
+
+func TestRunPostHook(t *testing.T) {
+	setting := MockServerSetting{Folder: t.TempDir(), Host: "0.0.0.0", Port: 12345}
+
+	if err := setting.RunPostHook("true"); err != nil {
+		t.Errorf("expect successful hook, got %v", err)
+	}
+	if err := setting.RunPostHook("false"); err == nil {
+		t.Errorf("expect error for failing hook")
+	}
+
+	// The hook receives the mock server details through the environment
+	output := filepath.Join(setting.Folder, "env.txt")
+	if err := setting.RunPostHook(`echo "$MOCK_TARGET_FOLDER $MOCK_HOST $MOCK_PORT" > ` + output); err != nil {
+		t.Fatalf("expect successful hook, got %v", err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("Failed to read hook output: %v", err)
+	}
+	if expected := setting.Folder + " 0.0.0.0 12345\n"; string(data) != expected {
+		t.Errorf("expect hook environment %q, got %q", expected, data)
+	}
+}
+
+func TestConvertOpenAPIToMockServerServers(t *testing.T) {
+	setting := ConvertOpenAPIToMockServer(loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Servers API
+  version: 1.0.0
+servers:
+  - url: http://localhost:8080/v1
+  - url: https://{env}.example.com/v1
+    variables:
+      env:
+        default: staging
+  - url: https://api.example.com/v1
+paths: {}
+`), Options{})
+
+	expected := []string{
+		"http://localhost:8080/v1",
+		"https://staging.example.com/v1",
+		"https://api.example.com/v1",
+	}
+	if strings.Join(setting.Servers, " ") != strings.Join(expected, " ") {
+		t.Errorf("expect servers %v, got %v", expected, setting.Servers)
+	}
+	if setting.Host != "localhost" || setting.Port != 8080 {
+		t.Errorf("expect host and port from the first server, got %s:%d", setting.Host, setting.Port)
+	}
+}
+
+func TestConvertOpenAPIToMockServerDescription(t *testing.T) {
+	setting := generateTestMock(t, `
+openapi: "3.0.0"
+info:
+  title: Description API
+  version: 1.0.0
+paths:
+  /users:
+    get:
+      operationId: listUsers
+      summary: List users
+      description: Returns all registered users.
+      responses:
+        '204':
+          description: No content
+  /groups:
+    get:
+      operationId: listGroups
+      summary: List groups
+      responses:
+        '204':
+          description: No content
+`, Options{})
+
+	descriptions := map[string]string{}
+	for _, request := range setting.Requests {
+		descriptions[request.Name] = request.Description
+	}
+	if descriptions["listUsers"] != "Returns all registered users." {
+		t.Errorf("expect operation description, got %q", descriptions["listUsers"])
+	}
+	if descriptions["listGroups"] != "List groups" {
+		t.Errorf("expect operation summary, got %q", descriptions["listGroups"])
+	}
+
+	data, err := os.ReadFile(filepath.Join(setting.Folder, "setting.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read setting.yaml: %v", err)
+	}
+	if !strings.Contains(string(data), "description: Returns all registered users.") {
+		t.Errorf("expect description in setting.yaml, got:\n%s", data)
+	}
+}
+
+const exampleSpec = `
+openapi: "3.0.0"
+info:
+  title: Example API
+  version: 1.0.0
+paths:
+  /both:
+    get:
+      operationId: getBoth
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              example: {"source": "example"}
+              examples:
+                named:
+                  value: {"source": "examples"}
+  /single:
+    get:
+      operationId: getSingle
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              example: {"source": "example"}
+`
+
+// responseSources returns the "source" field of each response body by operation id.
+func responseSources(t *testing.T, requests []Request) map[string][]string {
+	t.Helper()
+	sources := map[string][]string{}
+	for _, request := range requests {
+		for _, response := range request.Responses {
+			var body map[string]interface{}
+			if response.Body == nil || json.Unmarshal([]byte(*response.Body), &body) != nil {
+				t.Fatalf("expect JSON body for %s, got %v", request.Name, response.Body)
+			}
+			source, _ := body["source"].(string)
+			sources[request.Name] = append(sources[request.Name], source)
+		}
+	}
+	return sources
+}
+
+func TestExtractResponseExamplePriority(t *testing.T) {
+	spec := loadTestSpec(t, exampleSpec)
+
+	sources := responseSources(t, getRequests(spec, getSchemaExamples(spec, Options{}), Options{}))
+	if strings.Join(sources["getBoth"], ",") != "examples" {
+		t.Errorf("expect named examples to win by default, got %v", sources["getBoth"])
+	}
+	if strings.Join(sources["getSingle"], ",") != "example" {
+		t.Errorf("expect singular example as fallback, got %v", sources["getSingle"])
+	}
+
+	sources = responseSources(t, getRequests(spec, getSchemaExamples(spec, Options{}), Options{PreferExample: true}))
+	if strings.Join(sources["getBoth"], ",") != "example" {
+		t.Errorf("expect singular example to win when preferred, got %v", sources["getBoth"])
+	}
+}
+
+func TestExtractResponseEmptyExamplesWithSchema(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Example API
+  version: 1.0.0
+paths:
+  /empty:
+    get:
+      operationId: getEmpty
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Source"
+              examples:
+                first:
+                  summary: no value
+                second:
+                  summary: no value either
+  /named:
+    get:
+      operationId: getNamed
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Source"
+              examples:
+                named:
+                  value: {"source": "examples"}
+components:
+  schemas:
+    Source:
+      type: object
+      properties:
+        source:
+          type: string
+          example: schema
+`)
+
+	sources := responseSources(t, getRequests(spec, getSchemaExamples(spec, Options{}), Options{}))
+	if strings.Join(sources["getEmpty"], ",") != "schema" {
+		t.Errorf("expect schema fallback when all examples are empty, got %v", sources["getEmpty"])
+	}
+	if strings.Join(sources["getNamed"], ",") != "examples" {
+		t.Errorf("expect named examples by default, got %v", sources["getNamed"])
+	}
+
+	sources = responseSources(t, getRequests(spec, getSchemaExamples(spec, Options{}), Options{PreferSchema: true}))
+	if strings.Join(sources["getNamed"], ",") != "schema" {
+		t.Errorf("expect schema when preferred, got %v", sources["getNamed"])
+	}
+}
+
+func TestDefaultBodies(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Default API
+  version: 1.0.0
+paths:
+  /users:
+    get:
+      operationId: listUsers
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json; charset=utf-8: {}
+        '204':
+          description: No Content
+        '500':
+          description: Error
+          content:
+            application/json: {}
+  /readme:
+    get:
+      operationId: getReadme
+      responses:
+        '200':
+          description: OK
+          content:
+            text/plain:
+              example: Hello
+`)
+	requests := getRequests(spec, getSchemaExamples(spec, Options{}), Options{
+		DefaultBodies: map[string]string{"Application/JSON": "{}", "*": "OK"},
+	})
+
+	bodies := map[string]string{}
+	for _, request := range requests {
+		for _, response := range request.Responses {
+			if response.Body != nil {
+				bodies[fmt.Sprintf("%s %d", request.Name, response.Code)] = *response.Body
+			}
+		}
+	}
+	if bodies["listUsers 200"] != "{}" {
+		t.Errorf("expect the default body for the empty JSON response, got %q", bodies["listUsers 200"])
+	}
+	if _, ok := bodies["listUsers 204"]; ok {
+		t.Errorf("expect no body for a response without content")
+	}
+	if _, ok := bodies["listUsers 500"]; ok {
+		t.Errorf("expect no default body for an error response")
+	}
+	if bodies["getReadme 200"] != "Hello" {
+		t.Errorf("expect the example to be kept, got %q", bodies["getReadme 200"])
+	}
+}
+
+func TestExtractResponseExampleName(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Example API
+  version: 1.0.0
+paths:
+  /orders:
+    post:
+      operationId: createOrder
+      responses:
+        '201':
+          description: Created
+          content:
+            application/json:
+              examples:
+                happy-path:
+                  value: {"source": "happy-path"}
+                out-of-stock:
+                  value: {"source": "out-of-stock"}
+  /stock:
+    get:
+      operationId: getStock
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              examples:
+                full:
+                  value: {"source": "full"}
+                empty:
+                  value: {"source": "empty"}
+`)
+
+	requests := getRequests(spec, getSchemaExamples(spec, Options{}), Options{ExampleName: "happy-path"})
+	sources := responseSources(t, requests)
+	if strings.Join(sources["createOrder"], ",") != "happy-path" {
+		t.Errorf("expect only the selected example, got %v", sources["createOrder"])
+	}
+	if len(sources["getStock"]) != 2 {
+		t.Errorf("expect all examples when the name is absent, got %v", sources["getStock"])
+	}
+	for _, request := range requests {
+		if request.Name == "createOrder" && !strings.HasSuffix(request.Responses[0].Query, "&name=happy-path") {
+			t.Errorf("expect the example name in the query, got %s", request.Responses[0].Query)
+		}
+	}
+}
+
+func TestAddHealthRequest(t *testing.T) {
+	requests := addHealthRequest([]Request{{Name: "listUsers", Method: "GET", Path: "/users"}}, "/healthz", Options{})
+	if len(requests) != 2 {
+		t.Fatalf("expect health route to be added, got %d requests", len(requests))
+	}
+	health := requests[1]
+	if health.Method != "GET" || health.Path != "/healthz" || health.Responses[0].Code != 200 {
+		t.Errorf("unexpected health route %+v", health)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(*health.Responses[0].Body), &body); err != nil || body["status"] != "ok" {
+		t.Errorf("expect {\"status\":\"ok\"} body, got %s", *health.Responses[0].Body)
+	}
+
+	// The route is not added when the spec already defines it
+	requests = addHealthRequest([]Request{{Name: "health", Method: "get", Path: "/status"}}, "/status", Options{})
+	if len(requests) != 1 || requests[0].Name != "health" {
+		t.Errorf("expect existing health route to be kept, got %+v", requests)
+	}
+}
+
+func TestEnsureFolderRace(t *testing.T) {
+	folder := filepath.Join(t.TempDir(), "data", "Race_API")
+
+	// Another generation creates the folder right before this one does
+	defer func(original func(string, os.FileMode) error) { makeFolder = original }(makeFolder)
+	makeFolder = func(path string, perm os.FileMode) error {
+		if err := os.MkdirAll(path, perm); err != nil {
+			return err
+		}
+		return os.Mkdir(path, perm)
+	}
+	if err := ensureFolder(folder, 0755); err != nil {
+		t.Errorf("expect folder created concurrently to be accepted, got %v", err)
+	}
+}
+
+func TestCreateFolderConcurrently(t *testing.T) {
+	targetFolder := t.TempDir()
+	done := make(chan string)
+	for i := 0; i < 8; i++ {
+		go func() {
+			setting := MockServerSetting{Name: "Parallel API"}
+			if err := setting.CreateFolder(targetFolder); err != nil {
+				t.Errorf("expect folder created concurrently to be accepted, got %v", err)
+			}
+			done <- setting.Folder
+		}()
+	}
+	for i := 0; i < 8; i++ {
+		if folder := <-done; folder != targetFolder+"/data/Parallel_API" {
+			t.Errorf("unexpected folder %s", folder)
+		}
+	}
+	if info, err := os.Stat(filepath.Join(targetFolder, "data", "Parallel_API")); err != nil || !info.IsDir() {
+		t.Errorf("expect data folder to be created: %v", err)
+	}
+}
+
+func TestExtractResponseInlineSchema(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Inline API
+  version: 1.0.0
+paths:
+  /users/{id}:
+    get:
+      operationId: getUser
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  name:
+                    type: string
+                    example: Alice
+`)
+	requests := getRequests(spec, getSchemaExamples(spec, Options{}), Options{})
+
+	response := requests[0].Responses[0]
+	if response.Body == nil {
+		t.Fatalf("expect body generated from the inline schema")
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(*response.Body), &body); err != nil || body["name"] != "Alice" {
+		t.Errorf("expect {\"name\": \"Alice\"}, got %s", *response.Body)
+	}
+}
+
+func TestExtractResponseInlineArraySchema(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Inline API
+  version: 1.0.0
+paths:
+  /users:
+    get:
+      operationId: listUsers
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  type: object
+                  properties:
+                    name:
+                      type: string
+                      example: Alice
+`)
+	requests := getRequests(spec, getSchemaExamples(spec, Options{}), Options{})
+
+	response := requests[0].Responses[0]
+	if response.Body == nil {
+		t.Fatalf("expect body generated from the inline array schema")
+	}
+	var body []map[string]interface{}
+	if err := json.Unmarshal([]byte(*response.Body), &body); err != nil || len(body) != 1 || body[0]["name"] != "Alice" {
+		t.Errorf("expect [{\"name\": \"Alice\"}], got %s", *response.Body)
+	}
+}
+
+func TestGetRequestsExcludeDeprecated(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Deprecated API
+  version: 1.0.0
+paths:
+  /v1/users:
+    get:
+      operationId: listUsersV1
+      deprecated: true
+      responses:
+        '204':
+          description: No content
+  /v2/users:
+    get:
+      operationId: listUsersV2
+      responses:
+        '204':
+          description: No content
+`)
+
+	if requests := getRequests(spec, getSchemaExamples(spec, Options{}), Options{}); len(requests) != 2 {
+		t.Errorf("expect deprecated operations to be included by default, got %d requests", len(requests))
+	}
+	requests := getRequests(spec, getSchemaExamples(spec, Options{}), Options{ExcludeDeprecated: true})
+	if len(requests) != 1 || requests[0].Name != "listUsersV2" {
+		t.Errorf("expect only the active operation, got %+v", requests)
+	}
+}
+
+func TestGetRequestsOperation(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Users API
+  version: 1.0.0
+paths:
+  /users:
+    get:
+      operationId: listUsers
+      responses:
+        '204':
+          description: No content
+  /users/{id}:
+    get:
+      operationId: getUserById
+      responses:
+        '204':
+          description: No content
+    delete:
+      operationId: deleteUser
+      responses:
+        '204':
+          description: No content
+`)
+
+	requests := getRequests(spec, getSchemaExamples(spec, Options{}), Options{Operation: "getUserById"})
+	if len(requests) != 1 || requests[0].Name != "getUserById" {
+		t.Errorf("expect only the named operation, got %+v", requests)
+	}
+
+	if err := checkOperation(spec, "getUserById"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err := checkOperation(spec, "getUser")
+	if err == nil || err.Error() != `operation "getUser" not found, available operations: deleteUser, getUserById, listUsers` {
+		t.Errorf("expect an error listing the operations, got %v", err)
+	}
+}
+
+func TestSummary(t *testing.T) {
+	setting := generateTestMock(t, `
+openapi: "3.0.0"
+info:
+  title: Summary API
+  version: 1.0.0
+paths:
+  /users:
+    get:
+      operationId: listUsers
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              example: []
+        '404':
+          description: Not found
+  /groups:
+    get:
+      operationId: listGroups
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              example: []
+`, Options{})
+	const expected = "Generated 2 requests, 3 responses, 2 body files"
+	if summary := setting.Summary(); summary != expected {
+		t.Errorf("expect summary %q, got %q", expected, summary)
+	}
+
+	singleFile := generateTestMock(t, petSpec, Options{SingleFile: true})
+	if summary := singleFile.Summary(); !strings.HasSuffix(summary, ", 1 body files") {
+		t.Errorf("expect a single body file, got %q", summary)
+	}
+
+	// The summary is an info message, suppressed in quiet mode
+	var output bytes.Buffer
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+	defer func(level int) { logLevel = level }(logLevel)
+
+	infof("%s", setting.Summary())
+	if !strings.Contains(output.String(), expected) {
+		t.Errorf("expect summary to be logged, got %q", output.String())
+	}
+	output.Reset()
+	logLevel = levelWarn
+	infof("%s", setting.Summary())
+	if output.Len() != 0 {
+		t.Errorf("expect summary to be suppressed in quiet mode, got %q", output.String())
+	}
+}
+
+func TestSaveSettingFileExtensions(t *testing.T) {
+	setting := generateTestMock(t, petSpec, Options{Extensions: map[string]string{"text/plain": ".text"}})
+
+	files := map[string]string{}
+	for _, response := range setting.Requests[0].Responses {
+		if response.FilePath != nil {
+			files[response.ContentType()] = *response.FilePath
+		}
+	}
+	if files["application/json"] != "./data/Pet_API/GET/getPet/200/OK.json" {
+		t.Errorf("unexpected JSON file path %s", files["application/json"])
+	}
+	if files["text/plain"] != "./data/Pet_API/GET/getPet/200/OK.text" {
+		t.Errorf("unexpected text file path %s", files["text/plain"])
+	}
+	data, err := os.ReadFile(filepath.Join(setting.Folder, "GET", "getPet", "200", "OK.text"))
+	if err != nil || string(data) != "Tom" {
+		t.Errorf("expect text body in OK.text, got %q (%v)", data, err)
+	}
+}
+
+func TestExtractResponseCodesAllowlist(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Codes API
+  version: 1.0.0
+paths:
+  /users:
+    post:
+      operationId: createUser
+      responses:
+        '201':
+          description: Created
+        '204':
+          description: No content
+        '400':
+          description: Bad request
+        '500':
+          description: Server error
+`)
+
+	codes := func(requests []Request) (codes []int) {
+		for _, response := range requests[0].Responses {
+			codes = append(codes, response.Code)
+		}
+		return codes
+	}
+	if all := codes(getRequests(spec, getSchemaExamples(spec, Options{}), Options{})); len(all) != 4 {
+		t.Errorf("expect all responses without allowlist, got %v", all)
+	}
+	allowed := codes(getRequests(spec, getSchemaExamples(spec, Options{}), Options{Codes: []int{200, 201, 204}}))
+	if len(allowed) != 2 || allowed[0] != 201 || allowed[1] != 204 {
+		t.Errorf("expect only 201 and 204, got %v", allowed)
+	}
+}
+
+func TestConvertOpenAPIToMockServerSchemas(t *testing.T) {
+	setting := generateTestMock(t, `
+openapi: "3.0.0"
+info:
+  title: Schemas API
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    User:
+      type: object
+      properties:
+        name:
+          type: string
+          example: Alice
+    Group:
+      type: object
+      properties:
+        id:
+          type: integer
+          example: 7
+`, Options{EmitSchemas: true})
+
+	if len(setting.Schemas) != 2 {
+		t.Fatalf("expect 2 schema examples, got %v", setting.Schemas)
+	}
+	var user map[string]interface{}
+	if err := json.Unmarshal([]byte(setting.Schemas["#/components/schemas/User"]), &user); err != nil || user["name"] != "Alice" {
+		t.Errorf("unexpected User example %q", setting.Schemas["#/components/schemas/User"])
+	}
+
+	data, err := os.ReadFile(filepath.Join(setting.Folder, "schemas.json"))
+	if err != nil {
+		t.Fatalf("Failed to read schemas.json: %v", err)
+	}
+	var schemas map[string]map[string]interface{}
+	if err := json.Unmarshal(data, &schemas); err != nil {
+		t.Fatalf("Invalid schemas.json: %v", err)
+	}
+	if schemas["#/components/schemas/Group"]["id"] != 7.0 {
+		t.Errorf("unexpected schemas.json content: %s", data)
+	}
+
+	// An example that is not valid JSON is written as a string
+	setting.Schemas["#/components/schemas/Broken"] = "{not json"
+	if err := setting.saveSchemasFile(); err != nil {
+		t.Fatalf("Failed to save schemas.json with an invalid example: %v", err)
+	}
+	if data, err = os.ReadFile(filepath.Join(setting.Folder, "schemas.json")); err != nil {
+		t.Fatalf("Failed to read schemas.json: %v", err)
+	}
+	var saved map[string]interface{}
+	if err := json.Unmarshal(data, &saved); err != nil || saved["#/components/schemas/Broken"] != "{not json" {
+		t.Errorf("expect the invalid example as a string, got %s (%v)", data, err)
+	}
+}
+
+func TestSaveSettingCustomName(t *testing.T) {
+	yamlSetting := generateTestMock(t, petSpec, Options{SettingName: "config.yaml"})
+	data, err := os.ReadFile(filepath.Join(yamlSetting.Folder, "config.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read config.yaml: %v", err)
+	}
+	var yamlContent MockServerSetting
+	if err := yaml.Unmarshal(data, &yamlContent); err != nil || yamlContent.Name != "Pet API" || len(yamlContent.Requests) != 1 {
+		t.Errorf("invalid config.yaml (%v):\n%s", err, data)
+	}
+	if _, err := os.Stat(filepath.Join(yamlSetting.Folder, "setting.yaml")); !os.IsNotExist(err) {
+		t.Errorf("expect no setting.yaml when a custom name is used")
+	}
+
+	jsonSetting := generateTestMock(t, petSpec, Options{SettingName: "config.json"})
+	data, err = os.ReadFile(filepath.Join(jsonSetting.Folder, "config.json"))
+	if err != nil {
+		t.Fatalf("Failed to read config.json: %v", err)
+	}
+	var jsonContent map[string]interface{}
+	if err := json.Unmarshal(data, &jsonContent); err != nil {
+		t.Fatalf("invalid config.json (%v):\n%s", err, data)
+	}
+	if jsonContent["name"] != "Pet API" || jsonContent["swaggerEnabled"] != true {
+		t.Errorf("expect setting fields in config.json, got:\n%s", data)
+	}
+	if _, ok := jsonContent["Options"]; ok {
+		t.Errorf("expect options not to be saved in config.json")
+	}
+}
+
+func TestSaveSettingYAMLIndent(t *testing.T) {
+	setting := generateTestMock(t, petSpec, Options{YAMLIndent: 4})
+	data, err := os.ReadFile(filepath.Join(setting.Folder, "setting.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read setting.yaml: %v", err)
+	}
+	if !strings.Contains(string(data), "requests:\n    - name: getPet\n      method: GET") {
+		t.Errorf("expect 4 spaces indentation, got:\n%s", data)
+	}
+	var saved MockServerSetting
+	if err := yaml.Unmarshal(data, &saved); err != nil || len(saved.Requests) != 1 {
+		t.Errorf("invalid setting.yaml (%v):\n%s", err, data)
+	}
+
+	for _, value := range []string{"0", "1", "-2", "10", "16", "two"} {
+		if _, err := parseYAMLIndent(value); err == nil {
+			t.Errorf("expect error for indentation %q", value)
+		}
+	}
+}
+
+func TestSaveSettingTrailingNewline(t *testing.T) {
+	for trailingNewline, expected := range map[bool]string{
+		false: "{\n  \"name\": \"Tom\"\n}",
+		true:  "{\n  \"name\": \"Tom\"\n}\n",
+	} {
+		setting := generateTestMock(t, petSpec, Options{TrailingNewline: trailingNewline})
+		data, err := os.ReadFile(filepath.Join(setting.Folder, "GET", "getPet", "200", "OK.json"))
+		if err != nil {
+			t.Fatalf("Failed to read body file: %v", err)
+		}
+		if string(data) != expected {
+			t.Errorf("expect body %q with trailing newline %v, got %q", expected, trailingNewline, data)
+		}
+	}
+
+	// Bodies already ending with newlines keep a single one
+	setting := generateTestMock(t, `
+openapi: "3.0.0"
+info:
+  title: Text API
+  version: 1.0.0
+paths:
+  /name:
+    get:
+      operationId: getName
+      responses:
+        '200':
+          description: OK
+          content:
+            text/plain:
+              example: "Tom\n\n"
+`, Options{TrailingNewline: true})
+	data, err := os.ReadFile(filepath.Join(setting.Folder, "GET", "getName", "200", "OK.txt"))
+	if err != nil || string(data) != "Tom\n" {
+		t.Errorf("expect text body with one trailing newline, got %q (%v)", data, err)
+	}
+}
+
+func TestSaveSettingFlatten(t *testing.T) {
+	setting := generateTestMock(t, `
+openapi: "3.0.0"
+info:
+  title: Flat API
+  version: 1.0.0
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              examples:
+                cat:
+                  value: {"name": "Tom"}
+                dog:
+                  value: {"name": "Rex"}
+        '404':
+          description: Not Found
+          content:
+            text/plain:
+              example: Not found
+`, Options{Flatten: true, PathStyle: PathStyleRelative})
+
+	filePaths := []string{}
+	for _, response := range setting.Requests[0].Responses {
+		filePaths = append(filePaths, *response.FilePath)
+	}
+	sort.Strings(filePaths)
+	expected := "./GET_getPet_200.json,./GET_getPet_200_2.json,./GET_getPet_404.txt"
+	if strings.Join(filePaths, ",") != expected {
+		t.Errorf("expect flat file paths %s, got %v", expected, filePaths)
+	}
+	for _, filePath := range filePaths {
+		if _, err := os.Stat(filepath.Join(setting.Folder, filePath)); err != nil {
+			t.Errorf("expect body file %s: %v", filePath, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(setting.Folder, "GET")); !os.IsNotExist(err) {
+		t.Errorf("expect no method folder in the flat layout")
+	}
+}
+
+func TestSaveSettingPathStyles(t *testing.T) {
+	for style, expected := range map[string]string{
+		PathStyleTarget:   "./data/Pet_API/GET/getPet/200/OK.json",
+		PathStyleRelative: "./GET/getPet/200/OK.json",
+		PathStyleAbsolute: "/GET/getPet/200/OK.json",
+	} {
+		setting := generateTestMock(t, petSpec, Options{PathStyle: style})
+		var filePath string
+		for _, response := range setting.Requests[0].Responses {
+			if response.ContentType() == "application/json" {
+				filePath = *response.FilePath
+			}
+		}
+		if style == PathStyleAbsolute {
+			expected = filepath.Join(setting.Folder, expected)
+			if !filepath.IsAbs(filePath) {
+				t.Errorf("expect absolute path, got %s", filePath)
+			}
+		}
+		if filePath != expected {
+			t.Errorf("expect %s path %s, got %s", style, expected, filePath)
+		}
+
+		// The path resolves to the body file from the matching base folder
+		resolved := filePath
+		switch style {
+		case PathStyleTarget:
+			resolved = filepath.Join(setting.Folder, "..", "..", filePath)
+		case PathStyleRelative:
+			resolved = filepath.Join(setting.Folder, filePath)
+		}
+		if _, err := os.Stat(resolved); err != nil {
+			t.Errorf("expect %s path to resolve to the body file: %v", style, err)
+		}
+	}
+
+	if _, err := parsePathStyle("home"); err == nil {
+		t.Errorf("expect error for unknown path style")
+	}
+}
+
+func TestConvertOpenAPIToMockServerSettingDescription(t *testing.T) {
+	spec := `
+openapi: "3.0.0"
+info:
+  title: Description API
+  version: 1.0.0
+  description: A long description of the API
+paths: {}
+`
+	for expected, opts := range map[string]Options{
+		"A long description of the API": {},
+		"Users mock":                    {Description: "Users mock"},
+		"":                              {Description: "Users mock", NoDescription: true},
+	} {
+		_, saved := generateInto(t, t.TempDir(), spec, opts)
+		description, ok := saved["description"]
+		if expected == "" && ok {
+			t.Errorf("expect the description omitted, got %v", description)
+		}
+		if expected != "" && description != expected {
+			t.Errorf("expect the description %q, got %v", expected, description)
+		}
+	}
+}
+
+func TestParseOpenApiFileErrors(t *testing.T) {
+	folder := t.TempDir()
+	invalidFile := filepath.Join(folder, "invalid.yaml")
+	if err := os.WriteFile(invalidFile, []byte("openapi: [3.0.0\n"), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+	for file, expected := range map[string]int{
+		filepath.Join(folder, "missing.yaml"): ExitSpecNotFound,
+		invalidFile:                           ExitParse,
+	} {
+		if _, err := ParseOpenApiFile(file); err == nil || exitCode(err) != expected {
+			t.Errorf("expect an error with exit code %d for %s, got %v", expected, filepath.Base(file), err)
+		}
+	}
+}
+
+func TestSaveSettingDataDir(t *testing.T) {
+	for dataDir, opts := range map[string]Options{
+		"mocks/v1": {DataDir: "mocks/v1"},
+		"":         {NoDataDir: true},
+	} {
+		targetFolder := t.TempDir()
+		opts.Port, opts.Diff = 8080, filepath.Join(t.TempDir(), "diff.txt")
+		generateInto(t, targetFolder, petSpec, opts)
+		setting, _ := generateInto(t, targetFolder, petSpec, opts)
+
+		expectedFolder := filepath.Join(targetFolder, dataDir, "Pet_API")
+		if filepath.Clean(setting.Folder) != expectedFolder {
+			t.Errorf("expect the mock server folder %s, got %s", expectedFolder, setting.Folder)
+		}
+		prefix := "./Pet_API/"
+		if dataDir != "" {
+			prefix = "./" + dataDir + "/Pet_API/"
+		}
+		for _, request := range setting.Requests {
+			for _, response := range request.Responses {
+				if response.FilePath == nil {
+					continue
+				}
+				if !strings.HasPrefix(*response.FilePath, prefix) {
+					t.Errorf("expect the file path under %s, got %s", prefix, *response.FilePath)
+				}
+				if _, err := os.Stat(filepath.Join(targetFolder, *response.FilePath)); err != nil {
+					t.Errorf("expect the file path to resolve from the target folder: %v", err)
+				}
+			}
+		}
+
+		// The bodies of the previous generation are found in the data folder
+		if diff, err := os.ReadFile(opts.Diff); err != nil || len(diff) != 0 {
+			t.Errorf("expect no changes from the same spec, got %q (%v)", diff, err)
+		}
+	}
+
+	for _, value := range []string{"", ".", "..", "../mocks", "/mocks"} {
+		if _, err := parseDataDir(value); err == nil {
+			t.Errorf("expect data folder %q to be rejected", value)
+		}
+	}
+}
+
+func TestGetRequestsWebhooks(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.1.0"
+info:
+  title: Webhook API
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        '204':
+          description: No content
+webhooks:
+  newPet:
+    post:
+      operationId: newPetWebhook
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+      responses:
+        '200':
+          description: Received
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Ack'
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name:
+          type: string
+          example: Tom
+    Ack:
+      type: object
+      properties:
+        status:
+          type: string
+          example: received
+`)
+
+	requests := getRequests(spec, getSchemaExamples(spec, Options{}), Options{})
+	if len(requests) != 2 {
+		t.Fatalf("expect a path and a webhook request, got %d", len(requests))
+	}
+	for _, request := range requests {
+		switch request.Name {
+		case "listPets":
+			if request.Webhook {
+				t.Errorf("expect path operation not to be flagged as webhook")
+			}
+		case "newPetWebhook":
+			if !request.Webhook || request.Method != "POST" || request.Path != "/newPet" {
+				t.Errorf("unexpected webhook request %+v", request)
+			}
+			body := request.Responses[0].Body
+			if body == nil || !strings.Contains(*body, "received") {
+				t.Errorf("expect webhook response body from the referenced schema, got %v", body)
+			}
+		default:
+			t.Errorf("unexpected request %s", request.Name)
+		}
+	}
+}
+
+func TestGetRequestsCallbacks(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Callback API
+  version: 1.0.0
+paths:
+  /subscriptions:
+    post:
+      operationId: subscribe
+      responses:
+        '201':
+          description: Subscribed
+      callbacks:
+        onEvent:
+          '{$request.body#/callbackUrl}/events':
+            post:
+              operationId: eventCallback
+              responses:
+                '200':
+                  description: Received
+                  content:
+                    application/json:
+                      example: {"received": true}
+`)
+
+	if requests := getRequests(spec, getSchemaExamples(spec, Options{}), Options{}); len(requests) != 1 {
+		t.Errorf("expect callbacks to be ignored by default, got %d requests", len(requests))
+	}
+
+	requests := getRequests(spec, getSchemaExamples(spec, Options{}), Options{EmitCallbacks: true})
+	if len(requests) != 2 {
+		t.Fatalf("expect the operation and its callback, got %d requests", len(requests))
+	}
+	callback := requests[1]
+	if callback.Name != "eventCallback" || callback.Callback != "onEvent" || callback.Method != "POST" || callback.Path != "/events" {
+		t.Errorf("unexpected callback request %+v", callback)
+	}
+	if requests[0].Callback != "" {
+		t.Errorf("expect the operation not to be flagged as callback")
+	}
+	if body := callback.Responses[0].Body; body == nil || !strings.Contains(*body, "received") {
+		t.Errorf("expect callback response body, got %v", body)
+	}
+
+	if path := callbackPath("onDone", "{$request.query.url}"); path != "/onDone" {
+		t.Errorf("expect callback name as path, got %s", path)
+	}
+	if path := callbackPath("onDone", "https://example.com/hooks/{$request.body#/id}/done"); path != "/hooks/done" {
+		t.Errorf("expect path of the callback URL, got %s", path)
+	}
+}
+
+func TestExtractSchemaExampleEnumStrategy(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Enum API
+  version: 1.0.0
+paths: {}
+components:
+  schemas:
+    Order:
+      type: object
+      properties:
+        status:
+          type: string
+          enum: [placed, approved, delivered, cancelled]
+        note:
+          type: string
+          example: fixed
+          enum: [a, b]
+`)
+	schema := spec.Components.Schemas["Order"].Value
+	status := func(opts Options) interface{} {
+		t.Helper()
+		var order map[string]interface{}
+		if err := json.Unmarshal([]byte(extractSchemaExample(schema, opts)), &order); err != nil {
+			t.Fatalf("Invalid example JSON: %v", err)
+		}
+		if order["note"] != "fixed" {
+			t.Errorf("expect the example to take precedence over the enum, got %v", order["note"])
+		}
+		return order["status"]
+	}
+
+	seed := int64(42)
+	for strategy, expected := range map[string]interface{}{
+		"":         "placed",
+		EnumFirst:  "placed",
+		EnumLast:   "cancelled",
+		"index:2":  "delivered",
+		"index:10": "cancelled",
+	} {
+		if value := status(Options{EnumStrategy: strategy, Seed: &seed}); value != expected {
+			t.Errorf("expect %v with strategy %q, got %v", expected, strategy, value)
+		}
+	}
+
+	// The random strategy is reproducible with the same seed
+	first := status(Options{EnumStrategy: EnumRandom, Seed: &seed})
+	for i := 0; i < 5; i++ {
+		if value := status(Options{EnumStrategy: EnumRandom, Seed: &seed}); value != first {
+			t.Errorf("expect the same random value with the same seed, got %v and %v", first, value)
+		}
+	}
+
+	for _, strategy := range []string{"middle", "index:-1", "index:x"} {
+		if _, err := parseEnumStrategy(strategy); err == nil {
+			t.Errorf("expect error for enum strategy %q", strategy)
+		}
+	}
+}
+
+func TestExtractResponseDefaultAndRanges(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Range API
+  version: 1.0.0
+paths:
+  /only-ranges:
+    get:
+      operationId: getOnlyRanges
+      responses:
+        default:
+          description: Default
+          content:
+            application/json:
+              example: {"source": "default"}
+        4XX:
+          description: Client Error
+          content:
+            application/json:
+              example: {"source": "4XX"}
+        5XX:
+          description: Server Error
+          content:
+            application/json:
+              example: {"source": "5XX"}
+  /with-success:
+    get:
+      operationId: getWithSuccess
+      responses:
+        '201':
+          description: Created
+          content:
+            application/json:
+              example: {"source": "201"}
+        default:
+          description: Error
+          content:
+            application/json:
+              example: {"source": "default"}
+`)
+	requests := getRequests(spec, getSchemaExamples(spec, Options{}), Options{})
+
+	codes := map[string][]int{}
+	for _, request := range requests {
+		for _, response := range request.Responses {
+			codes[request.Name] = append(codes[request.Name], response.Code)
+		}
+	}
+	if fmt.Sprint(codes["getOnlyRanges"]) != "[200 400 500]" {
+		t.Errorf("expect default as 200 and ranges expanded, sorted, got %v", codes["getOnlyRanges"])
+	}
+	if fmt.Sprint(codes["getWithSuccess"]) != "[201 500]" {
+		t.Errorf("expect default as 500 next to a success response, got %v", codes["getWithSuccess"])
+	}
+
+	sources := responseSources(t, requests)
+	if strings.Join(sources["getOnlyRanges"], ",") != "default,4XX,5XX" {
+		t.Errorf("unexpected bodies %v", sources["getOnlyRanges"])
+	}
+	for _, request := range requests {
+		if request.Name == "getOnlyRanges" && !strings.HasPrefix(request.Responses[1].Query, "?key=4XX&") {
+			t.Errorf("expect the original key in the query, got %s", request.Responses[1].Query)
+		}
+	}
+}
+
+func TestExtractResponseWildcardKeys(t *testing.T) {
+	spec := `
+openapi: "3.0.0"
+info:
+  title: Key API
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      responses:
+        '200':
+          description: OK
+        4XX:
+          description: Client Error
+        default:
+          description: Error
+`
+	setting, saved := generateInto(t, t.TempDir(), spec, Options{DefaultCode: 503})
+
+	keys := map[int]string{}
+	for _, response := range setting.Requests[0].Responses {
+		keys[response.Code] = response.Key
+	}
+	if fmt.Sprint(keys) != "map[200: 400:4XX 503:default]" {
+		t.Errorf("expect the keys of the wildcard and default responses, got %v", keys)
+	}
+
+	// The saved setting keeps the keys
+	savedKeys := []string{}
+	for _, response := range savedRequest(saved, "/pets")["responses"].([]interface{}) {
+		response := response.(map[string]interface{})
+		savedKeys = append(savedKeys, fmt.Sprintf("%v:%v", response["code"], response["key"]))
+	}
+	if strings.Join(savedKeys, ",") != "200:<nil>,400:4XX,503:default" {
+		t.Errorf("expect the keys saved in the setting, got %v", savedKeys)
+	}
+}
+
+func TestExtractResponseNoContentKeys(t *testing.T) {
+	spec := `
+openapi: "3.0.0"
+info:
+  title: Key API
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      responses:
+        '200':
+          description: OK
+        '500':
+          description: Server Error
+        4XX:
+          description: Client Error
+        default:
+          description: Error
+`
+	queries := []string{}
+	for _, response := range ConvertOpenAPIToMockServer(loadTestSpec(t, spec), Options{}).Requests[0].Responses {
+		queries = append(queries, fmt.Sprintf("%d%s", response.Code, response.Query))
+	}
+	if strings.Join(queries, ",") != "200?key=200,400?key=4XX,500?key=500,500?key=default" {
+		t.Errorf("expect the response keys in the queries, got %v", queries)
+	}
+}
+
+func TestExtractResponseSelectorParams(t *testing.T) {
+	spec := loadTestSpec(t, exampleSpec)
+	params := map[string]string{}
+	for _, value := range []string{"__mock", "name=__example"} {
+		if err := parseSelectorParam(value, params); err != nil {
+			t.Fatalf("unexpected error for %q: %v", value, err)
+		}
+	}
+	requests := getRequests(spec, getSchemaExamples(spec, Options{}), Options{SelectorParams: params})
+
+	queries := map[string]string{}
+	for _, request := range requests {
+		queries[request.Name] = request.Responses[0].Query
+	}
+	if queries["getBoth"] != "?__mock=200&contentType=application/json&__example=named" {
+		t.Errorf("expect renamed selector params, got %s", queries["getBoth"])
+	}
+	if queries["getSingle"] != "?__mock=200&contentType=application/json" {
+		t.Errorf("expect renamed key param, got %s", queries["getSingle"])
+	}
+
+	for _, value := range []string{"code=__mock", "key=", "key=a&b"} {
+		if err := parseSelectorParam(value, map[string]string{}); err == nil {
+			t.Errorf("expect error for %q", value)
+		}
+	}
+}
+
+func TestExtractResponseDefaultNamedExamples(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Default API
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        '200':
+          description: OK
+          content:
+            application/xml:
+              example: <pets/>
+            application/json:
+              examples:
+                dogs:
+                  value: [{"name": "Rex"}]
+                cats:
+                  value: [{"name": "Tom"}]
+`)
+	for i := 0; i < 10; i++ {
+		defaults := []string{}
+		for _, response := range getRequests(spec, getSchemaExamples(spec, Options{}), Options{})[0].Responses {
+			if response.Default {
+				defaults = append(defaults, response.Query)
+			}
+		}
+		if strings.Join(defaults, ",") != "?key=200&contentType=application/json&name=cats" {
+			t.Fatalf("expect the first named example of the first content type to be the default, got %v", defaults)
+		}
+	}
+}
+
+func TestExtractResponseDefault(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Default API
+  version: 1.0.0
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      responses:
+        '404':
+          description: Not Found
+          content:
+            application/json:
+              example: {"source": "404"}
+        '200':
+          description: OK
+          content:
+            application/json:
+              example: {"source": "200"}
+  /errors:
+    get:
+      operationId: getErrors
+      responses:
+        '500':
+          description: Error
+`)
+	requests := getRequests(spec, getSchemaExamples(spec, Options{}), Options{})
+
+	for _, request := range requests {
+		defaults := []int{}
+		for _, response := range request.Responses {
+			if response.Default {
+				defaults = append(defaults, response.Code)
+			}
+		}
+		switch request.Name {
+		case "getPet":
+			if fmt.Sprint(defaults) != "[200]" {
+				t.Errorf("expect the 200 response to be the default, got %v", defaults)
+			}
+		case "getErrors":
+			if len(defaults) != 0 {
+				t.Errorf("expect no default without a success response, got %v", defaults)
+			}
+		}
+	}
+}
+
+func TestExtractResponseReparseStringExamples(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: String API
+  version: 1.0.0
+paths:
+  /json:
+    get:
+      operationId: getJSON
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              examples:
+                tom:
+                  value: '{"name":"Tom","age":3}'
+  /yaml:
+    get:
+      operationId: getYAML
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              example: "name: Tom\nage: 3"
+  /text:
+    get:
+      operationId: getText
+      responses:
+        '200':
+          description: OK
+          content:
+            text/plain:
+              example: Hello, Tom
+  /error:
+    get:
+      operationId: getError
+      responses:
+        '200':
+          description: OK
+          content:
+            text/plain:
+              example: "Error: not found"
+`)
+	bodies := func(opts Options) map[string]string {
+		bodies := map[string]string{}
+		for _, request := range getRequests(spec, getSchemaExamples(spec, opts), opts) {
+			bodies[request.Name] = *request.Responses[0].Body
+		}
+		return bodies
+	}
+
+	reparsed := bodies(Options{ReparseStringExamples: true})
+	expected := "{\n  \"name\": \"Tom\",\n  \"age\": 3\n}"
+	if reparsed["getJSON"] != expected || reparsed["getYAML"] != expected {
+		t.Errorf("expect stringified examples to be parsed, got %q and %q", reparsed["getJSON"], reparsed["getYAML"])
+	}
+	if reparsed["getText"] != "Hello, Tom" {
+		t.Errorf("expect plain strings to be kept, got %q", reparsed["getText"])
+	}
+	if reparsed["getError"] != "Error: not found" {
+		t.Errorf("expect text/plain examples to be kept, got %q", reparsed["getError"])
+	}
+	if kept := bodies(Options{}); kept["getJSON"] != `{"name":"Tom","age":3}` {
+		t.Errorf("expect stringified examples kept by default, got %q", kept["getJSON"])
+	}
+}
+
+func TestConvertOpenAPIToMockServerSpecSeed(t *testing.T) {
+	port := func(spec string, opts Options) int {
+		return ConvertOpenAPIToMockServer(loadTestSpec(t, spec), opts).Port
+	}
+
+	if first, second := port(exampleSpec, Options{}), port(exampleSpec, Options{}); first != second {
+		t.Errorf("expect identical specs to yield identical ports, got %d and %d", first, second)
+	}
+	if first, second := port(exampleSpec, Options{}), port(mergeSpec, Options{}); first == second {
+		t.Errorf("expect different specs to yield different ports, got %d", first)
+	}
+	seed := int64(42)
+	if seeded := port(exampleSpec, Options{Seed: &seed}); seeded != randomPort(seed) {
+		t.Errorf("expect the explicit seed to override the spec seed, got %d", seeded)
+	}
+	if p := port(exampleSpec, Options{}); p < 10000 || p > 60000 {
+		t.Errorf("expect a port from 10000 to 60000, got %d", p)
+	}
+}
+
+// fakerSpec draws faker values for several schemas, paths, content types and
+// named examples, so that the iteration order shows in the generated tree.
+const fakerSpec = `
+openapi: "3.0.0"
+info:
+  title: Faker API
+  version: 1.0.0
+paths:
+  /users:
+    get:
+      operationId: getUsers
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+            application/xml:
+              schema:
+                $ref: '#/components/schemas/Company'
+        '404':
+          description: Not found
+          content:
+            application/json:
+              examples:
+                missing:
+                  value: {"error": "missing"}
+                gone:
+                  value: {"error": "gone"}
+  /companies:
+    get:
+      operationId: getCompanies
+      parameters:
+        - name: q
+          in: query
+          schema:
+            type: string
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Company'
+            text/plain:
+              schema:
+                type: object
+                properties:
+                  word:
+                    type: string
+                    x-faker: lorem.word
+    post:
+      operationId: createCompany
+      responses:
+        '201':
+          description: Created
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/City'
+components:
+  schemas:
+    User:
+      type: object
+      properties:
+        name:
+          type: string
+          x-faker: name.fullName
+        email:
+          type: string
+          x-faker: internet.email
+    Company:
+      type: object
+      properties:
+        name:
+          type: string
+          x-faker: company.name
+    City:
+      type: object
+      properties:
+        city:
+          type: string
+          x-faker: address.city
+`
+
+func TestConvertOpenAPIToMockServerDeterministic(t *testing.T) {
+	first, _ := generateInto(t, t.TempDir(), fakerSpec, Options{})
+	expected := readTree(t, first.Folder)
+	for i := 0; i < 5; i++ {
+		setting, _ := generateInto(t, t.TempDir(), fakerSpec, Options{})
+		tree := readTree(t, setting.Folder)
+		if len(tree) != len(expected) {
+			t.Fatalf("expect %d files on each run, got %d", len(expected), len(tree))
+		}
+		for path, data := range expected {
+			if tree[path] != data {
+				t.Fatalf("expect %s to be the same on each run, got:\n%s\nand:\n%s", path, data, tree[path])
+			}
+		}
+	}
+}
+
+func TestRandomPort(t *testing.T) {
+	ports := map[int]bool{}
+	for seed := int64(0); seed < 100; seed++ {
+		port := randomPort(seed)
+		if port < 10000 || port > 60000 {
+			t.Fatalf("expect a port from 10000 to 60000, got %d", port)
+		}
+		ports[port] = true
+	}
+	if len(ports) < 90 {
+		t.Errorf("expect the ports to vary with the seed, got %d distinct ports", len(ports))
+	}
+	if first, second := randomPort(7), randomPort(7); first != second {
+		t.Errorf("expect a seed to keep its port, got %d and %d", first, second)
+	}
+}
+
+func TestGetRequestsEmbeddedQuery(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Search API
+  version: 1.0.0
+paths:
+  /search?type=x:
+    get:
+      operationId: search
+      responses:
+        '204':
+          description: No content
+`)
+	requests := getRequests(spec, getSchemaExamples(spec, Options{}), Options{})
+	if len(requests) != 1 || requests[0].Path != "/search" {
+		t.Errorf("expect the embedded query to be stripped, got %+v", requests)
+	}
+
+	setting := generateTestMock(t, `
+openapi: "3.0.0"
+info:
+  title: Search API
+  version: 1.0.0
+paths:
+  /search?type=x:
+    get:
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              example: []
+`, Options{PathStyle: PathStyleRelative})
+	if filePath := *setting.Requests[0].Responses[0].FilePath; filePath != "./GET/search/200/OK.json" {
+		t.Errorf("expect a clean folder, got %s", filePath)
+	}
+}
+
+func TestSaveSettingFileModes(t *testing.T) {
+	fileMode, err := parseFileMode("0600")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	dirMode, err := parseFileMode("700")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	setting := generateTestMock(t, petSpec, Options{FileMode: fileMode, DirMode: dirMode, PathStyle: PathStyleRelative})
+
+	bodyFile := filepath.Join(setting.Folder, *setting.Requests[0].Responses[0].FilePath)
+	for path, expected := range map[string]os.FileMode{
+		filepath.Join(setting.Folder, "setting.yaml"): 0600,
+		bodyFile:               0600,
+		filepath.Dir(bodyFile): 0700,
+		setting.Folder:         0700,
+	} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("Failed to stat %s: %v", path, err)
+		}
+		if mode := info.Mode().Perm(); mode != expected {
+			t.Errorf("expect mode %o for %s, got %o", expected, path, mode)
+		}
+	}
+
+	for _, value := range []string{"0899", "rw", "0", "01777"} {
+		if _, err := parseFileMode(value); err == nil {
+			t.Errorf("expect error for %q", value)
+		}
+	}
+}
+
+func TestGetRequestsCreatedBody(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Pet API
+  version: 1.0.0
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      requestBody:
+        content:
+          application/json:
+            example: {"source": "request"}
+      responses:
+        '201':
+          description: Created
+        '400':
+          description: Bad Request
+    put:
+      operationId: replacePets
+      requestBody:
+        content:
+          application/json:
+            example: {"source": "request"}
+      responses:
+        '201':
+          description: Created
+`)
+	requests := getRequests(spec, getSchemaExamples(spec, Options{}), Options{})
+
+	for _, request := range requests {
+		switch request.Name {
+		case "createPet":
+			created := request.Responses[0]
+			if created.Body == nil || *created.Body != "{\n  \"source\": \"request\"\n}" || created.ContentType() != "application/json" {
+				t.Errorf("expect the created body to echo the request body, got %v (%s)", created.Body, created.ContentType())
+			}
+			if request.Responses[1].Body != nil {
+				t.Errorf("expect no body for the 400 response, got %s", *request.Responses[1].Body)
+			}
+		case "replacePets":
+			if request.Responses[0].Body != nil {
+				t.Errorf("expect no echoed body for other methods, got %s", *request.Responses[0].Body)
+			}
+		}
+	}
+}
+
+func TestConvertOpenAPIToMockServerSecurityHeaders(t *testing.T) {
+	spec := `
+openapi: "3.0.0"
+info:
+  title: Secure API
+  version: 1.0.0
+paths: {}
+components:
+  securitySchemes:
+    apiKey:
+      type: apiKey
+      in: header
+      name: X-API-Key
+    otherApiKey:
+      type: apiKey
+      in: header
+      name: x-api-key
+    queryKey:
+      type: apiKey
+      in: query
+      name: token
+    bearer:
+      type: http
+      scheme: bearer
+    basic:
+      type: http
+      scheme: basic
+`
+	_, saved := generateInto(t, t.TempDir(), spec, Options{Port: 8080})
+	headers, _ := saved["headers"].([]interface{})
+	var got []string
+	for _, header := range headers {
+		header := header.(map[string]interface{})
+		got = append(got, fmt.Sprintf("%v: %v", header["name"], header["value"]))
+	}
+	expected := "Authorization: Bearer <token>,X-API-Key: <value>"
+	if strings.Join(got, ",") != expected {
+		t.Errorf("expect the headers %s, got %v", expected, got)
+	}
+}
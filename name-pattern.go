@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// parseNamePattern compiles the pattern extracting the name of the responses
+// from their description.
+func parseNamePattern(value string) (*regexp.Regexp, error) {
+	pattern, err := regexp.Compile(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name pattern %q: %w", value, err)
+	}
+	return pattern, nil
+}
+
+// responseName returns the name of a response from its description, stripped
+// of its Markdown formatting with the plain names option. With a
+// name pattern, the name is the replacement expanded from the first match of
+// the pattern, by default its first capture group or the whole match. The
+// description is used as is when the pattern does not match or expands to
+// nothing.
+func (o Options) responseName(description string) string {
+	if o.PlainNames {
+		description = plainText(description)
+	}
+	if o.NamePattern == nil {
+		return cleanFolderName(description)
+	}
+	match := o.NamePattern.FindStringSubmatchIndex(description)
+	if match == nil {
+		return cleanFolderName(description)
+	}
+	replacement := o.NameReplacement
+	if replacement == "" {
+		replacement = "$0"
+		if o.NamePattern.NumSubexp() > 0 {
+			replacement = "$1"
+		}
+	}
+	name := cleanFolderName(string(o.NamePattern.ExpandString(nil, replacement, description, match)))
+	if name == "" {
+		return cleanFolderName(description)
+	}
+	return name
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+const namePatternSpec = `
+openapi: "3.0.0"
+info:
+  title: Name API
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      responses:
+        "200":
+          description: "[PET_LIST] Returns the list of all the pets of the store"
+          content:
+            application/json:
+              example: []
+        "404":
+          description: Nothing found
+`
+
+func TestNamePattern(t *testing.T) {
+	opts := Options{NamePattern: regexp.MustCompile(`^\[(\w+)\]`)}
+	setting := ConvertOpenAPIToMockServer(loadTestSpec(t, namePatternSpec), opts)
+
+	names := map[int]string{}
+	for _, response := range setting.Requests[0].Responses {
+		names[response.Code] = response.Name
+	}
+	if names[200] != "PET_LIST" {
+		t.Errorf("Expected the name to be the captured code, got %q", names[200])
+	}
+	if names[404] != "Nothing_found" {
+		t.Errorf("Expected the description as name without a match, got %q", names[404])
+	}
+}
+
+func TestResponseName(t *testing.T) {
+	cases := []struct {
+		pattern     string
+		replacement string
+		description string
+		name        string
+	}{
+		{"", "", "List all pets", "List_all_pets"},
+		{`^\w+`, "", "Created the pet", "Created"},
+		{`(\w+) (\w+)`, "$2-$1", "pet created", "created-pet"},
+		{`\[(\w*)\]`, "", "[] empty code", "[]_empty_code"},
+	}
+	for _, c := range cases {
+		opts := Options{NameReplacement: c.replacement}
+		if c.pattern != "" {
+			opts.NamePattern = regexp.MustCompile(c.pattern)
+		}
+		if name := opts.responseName(c.description); name != c.name {
+			t.Errorf("Expected the name of %q with %q to be %q, got %q", c.description, c.pattern, c.name, name)
+		}
+	}
+}
@@ -0,0 +1,437 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Path styles of the file paths recorded in the setting.
+const (
+	PathStyleTarget   = "target"   // relative to the target folder, e.g. ./data/<name>/<file>
+	PathStyleRelative = "relative" // relative to the setting file
+	PathStyleAbsolute = "absolute" // absolute path
+)
+
+// Enum strategies choosing the value of an enum in the generated examples.
+const (
+	EnumFirst       = "first"  // the first value
+	EnumLast        = "last"   // the last value
+	EnumRandom      = "random" // a random value, reproducible with a seed
+	enumIndexPrefix = "index:" // prefix of the "index:N" strategy
+)
+
+// Selector params of the query strings selecting a response of a request.
+const (
+	SelectorKey         = "key"         // the response code
+	SelectorContentType = "contentType" // the content type of the response
+	SelectorName        = "name"        // the name of the example
+)
+
+// Options holds the settings that control how the mock server is generated.
+type Options struct {
+	// Strict fails the generation on issues of the spec that are otherwise only
+	// reported, like unused component schemas.
+	Strict bool
+
+	// StrictExamples fails the generation when an example of a JSON response
+	// violates its schema.
+	StrictExamples bool
+
+	// Host and Port of the mock server, overriding the ones of the first server
+	// of the spec when set.
+	Host string
+	Port int
+
+	// SingleFile writes all response bodies into a single bodies.json document
+	// instead of one file per response.
+	SingleFile bool
+
+	// TrailingNewline ends each body file with exactly one newline. By default
+	// the bodies are written as is.
+	TrailingNewline bool
+
+	// PostHook is a shell command executed after the generation completes.
+	PostHook string
+
+	// PreferExample uses the singular example of a media type even when it also
+	// has named examples. By default the singular example is only a fallback.
+	PreferExample bool
+
+	// PreferSchema generates the body from the schema of a media type even when
+	// it also has named examples. The schema is always used when none of the
+	// named examples has a value.
+	PreferSchema bool
+
+	// Environment selects the example values of this environment, given by the
+	// x-mock-env extension of the examples, over their default value.
+	Environment string
+
+	// ReparseStringExamples parses the examples of JSON and YAML content types
+	// holding a stringified JSON or YAML document, so that their bodies are
+	// structured and indented.
+	ReparseStringExamples bool
+
+	// EnumStrategy chooses the value of an enum in the generated examples, one of
+	// the Enum constants or "index:N". Defaults to EnumFirst.
+	EnumStrategy string
+
+	// Seed makes the random values of the generated examples reproducible. A
+	// random seed is used when nil.
+	Seed *int64
+
+	// ExampleName selects the named example to generate. Responses that have an
+	// example with this name only get that one; others keep all their examples.
+	ExampleName string
+
+	// EmitHealth adds a GET route on HealthPath returning {"status":"ok"}, unless
+	// the spec already defines one.
+	EmitHealth bool
+	HealthPath string
+
+	// Operation limits the generation to the operation with this id.
+	Operation string
+
+	// ExcludeDeprecated skips the operations marked as deprecated.
+	ExcludeDeprecated bool
+
+	// Quiet only logs warnings and errors, without progress or summary.
+	Quiet bool
+
+	// Extensions overrides the file extension used for the bodies of a content
+	// type, e.g. "text/html" => ".htm".
+	Extensions map[string]string
+
+	// DefaultBodies is the body of the success responses nothing else generates
+	// one for, keyed by content type, "*" matching any content type.
+	DefaultBodies map[string]string
+
+	// ContentTypes overrides the Content-Type header of the responses of a route,
+	// keyed by "METHOD path", e.g. "GET /legacy" => "text/xml".
+	ContentTypes map[string]string
+
+	// DefaultCode is the status code of the default responses of the spec. By
+	// default, 200 when the operation declares no success response, else 500.
+	DefaultCode int
+
+	// Codes restricts the generated responses to these codes. All responses are
+	// generated when empty.
+	Codes []int
+
+	// SchemasOnly only writes the example of each component schema into
+	// schemas.json of the target folder, skipping the requests and responses.
+	SchemasOnly bool
+
+	// EmitErrors writes errors.json, cataloging the distinct 4XX and 5XX
+	// responses of all operations.
+	EmitErrors bool
+
+	// Report writes refs.json, counting the references to each component schema.
+	Report bool
+
+	// Flatten writes all body files directly into the mock server folder, named
+	// <method>_<name>_<code>, instead of a folder per request and code.
+	Flatten bool
+
+	// BasePort is the first port given to the mock server, incremented past the
+	// ports of the other mock servers of the target folder so that they can run
+	// together. The port option takes precedence.
+	BasePort int
+
+	// PortsLock records the name, host and port of the mock server in the
+	// ports.lock file of the target folder, for the supervisors to discover.
+	PortsLock bool
+
+	// Pad is the size in bytes the response bodies are padded up to, unless
+	// their x-mock-pad-bytes extension gives another one. No padding when 0.
+	Pad int
+
+	// EmitCacheHeaders adds ETag and Last-Modified headers to the responses
+	// with a body, the ETag hashed from the body.
+	EmitCacheHeaders bool
+
+	// SpecPointer is a JSON Pointer to the part of the spec to generate, e.g.
+	// /paths/~1users, given as the fragment of the OpenAPI file.
+	SpecPointer string
+
+	// Diff writes the routes, response codes and bodies changed since the
+	// previous generation into this file, or to the standard output for "-".
+	Diff string
+
+	// PlainNames strips the Markdown formatting of the descriptions, e.g. links
+	// and emphasis, before the names of the responses are derived from them.
+	PlainNames bool
+
+	// NamePattern extracts the name of the responses, and so of their body
+	// files, from their description, e.g. a bracketed code. The description is
+	// used when unset or when it does not match.
+	NamePattern *regexp.Regexp
+
+	// NameReplacement is the name expanded from the match of NamePattern, e.g.
+	// "$1". Defaults to the first capture group, or the whole match without one.
+	NameReplacement string
+
+	// Rewrites rewrite the paths of the requests, e.g. /old/{id}=>/new/{id}, with
+	// the first matching one.
+	Rewrites []PathRewrite
+
+	// TrimCommonPrefix strips the longest path prefix shared by all operations,
+	// e.g. /api/v1, from the paths of the requests.
+	TrimCommonPrefix bool
+
+	// EmitSchemas writes the generated example of each component schema into
+	// schemas.json.
+	EmitSchemas bool
+
+	// Description replaces the description of the spec in the setting.
+	// NoDescription omits the description from the setting.
+	Description   string
+	NoDescription bool
+
+	// DataDir is the folder of the target folder the mock servers are written
+	// into, "data" by default. NoDataDir writes them directly into the target
+	// folder.
+	DataDir   string
+	NoDataDir bool
+
+	// FolderTemplate is the name of the mock server folder, with the {name},
+	// {version} and {date} tokens replaced. Defaults to the cleaned name.
+	FolderTemplate string
+
+	// SettingName is the file name of the mock server setting, "setting.yaml" by
+	// default. A ".json" name writes the setting in JSON format.
+	SettingName string
+
+	// YAMLIndent is the number of spaces the YAML setting is indented with,
+	// from 2 to 9. Defaults to 2.
+	YAMLIndent int
+
+	// YAMLAnchors writes the header lists repeated across the YAML setting once,
+	// with an anchor, and refers to them with aliases.
+	YAMLAnchors bool
+
+	// PathStyle is the style of the file paths recorded in the setting, one of
+	// the PathStyle constants. Defaults to PathStyleTarget.
+	PathStyle string
+
+	// EmitCallbacks adds a request for each callback declared on the operations.
+	EmitCallbacks bool
+
+	// EmitFragments writes a minimal OpenAPI document describing only the
+	// operation into each request folder.
+	EmitFragments bool
+
+	// EmitRequestExamples writes the examples of the request bodies into a
+	// request folder under the folder of each request.
+	EmitRequestExamples bool
+
+	// NoCopySpec skips copying the OpenAPI file into the mock server folder.
+	NoCopySpec bool
+
+	// ExternalRefs resolves the references of the OpenAPI file to other files
+	// or URLs. They are rejected by default, as they read any URI the spec
+	// names.
+	ExternalRefs bool
+
+	// Zip is the path of a zip archive the mock server folder is packaged into
+	// after the generation. No archive is written when empty.
+	Zip string
+
+	// Index writes index.json, listing the routes with their response codes and
+	// body files for tooling.
+	Index bool
+
+	// Export writes a script calling the routes, in one of the Export formats,
+	// e.g. a k6 load-test skeleton.
+	Export string
+
+	// SelectorParams renames the selector params of the generated query
+	// strings, keyed by one of the Selector constants.
+	SelectorParams map[string]string
+
+	// Merge updates the existing setting file instead of overwriting it,
+	// keeping the fields edited by hand, e.g. headers, queries and delays.
+	Merge bool
+
+	// Prune removes the requests no longer in the spec when merging.
+	Prune bool
+
+	// FileMode and DirMode are the permissions of the generated files and
+	// folders, before the umask. Default to 0644 and 0755.
+	FileMode os.FileMode
+	DirMode  os.FileMode
+
+	random      *rand.Rand // random source shared by a conversion, see newRandom
+	specDir     string     // folder of the OpenAPI file, the CSV files are relative to
+	specModTime time.Time  // modification time of the OpenAPI file, for Last-Modified
+	sandbox     bool       // read no other file than the OpenAPI one, for untrusted specs
+}
+
+// parsePathStyle validates a path style option.
+func parsePathStyle(value string) (string, error) {
+	switch value {
+	case PathStyleTarget, PathStyleRelative, PathStyleAbsolute:
+		return value, nil
+	}
+	return "", fmt.Errorf("invalid path style %q, expected %s, %s or %s", value, PathStyleTarget, PathStyleRelative, PathStyleAbsolute)
+}
+
+// parseYAMLIndent validates the indentation of the YAML setting, from 2 to 9
+// spaces, the ones the YAML encoder supports.
+func parseYAMLIndent(value string) (int, error) {
+	indent, err := strconv.Atoi(value)
+	if err != nil || indent < 2 || indent > 9 {
+		return 0, fmt.Errorf("invalid YAML indentation %q, expected 2 to 9 spaces", value)
+	}
+	return indent, nil
+}
+
+// parseEnumStrategy validates an enum strategy: first, last, random or index:N.
+func parseEnumStrategy(value string) (string, error) {
+	switch value {
+	case EnumFirst, EnumLast, EnumRandom:
+		return value, nil
+	}
+	if index, ok := strings.CutPrefix(value, enumIndexPrefix); ok {
+		if n, err := strconv.Atoi(index); err == nil && n >= 0 {
+			return value, nil
+		}
+	}
+	return "", fmt.Errorf("invalid enum strategy %q, expected %s, %s, %s or %sN", value, EnumFirst, EnumLast, EnumRandom, enumIndexPrefix)
+}
+
+// parseSelectorParam parses a renamed selector param, either "<selector>=<name>"
+// or a plain name renaming the key selector, into params.
+func parseSelectorParam(value string, params map[string]string) error {
+	selector, name, ok := strings.Cut(value, "=")
+	if !ok {
+		selector, name = SelectorKey, value
+	}
+	selector, name = strings.TrimSpace(selector), strings.TrimSpace(name)
+	switch selector {
+	case SelectorKey, SelectorContentType, SelectorName:
+	default:
+		return fmt.Errorf("invalid selector %q, expected %s, %s or %s", selector, SelectorKey, SelectorContentType, SelectorName)
+	}
+	if name == "" || strings.ContainsAny(name, "?&=# ") {
+		return fmt.Errorf("invalid selector param name %q", name)
+	}
+	params[selector] = name
+	return nil
+}
+
+// selectorParam returns the name of a selector param in the query strings.
+func (o Options) selectorParam(selector string) string {
+	if name, ok := o.SelectorParams[selector]; ok {
+		return name
+	}
+	return selector
+}
+
+// selectorQuery builds the query string selecting a response from pairs of
+// selectors and values, e.g. selectorQuery(SelectorKey, "200").
+func (o Options) selectorQuery(pairs ...string) string {
+	params := []string{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		params = append(params, o.selectorParam(pairs[i])+"="+pairs[i+1])
+	}
+	return "?" + strings.Join(params, "&")
+}
+
+// conversionSeed returns the seed of the random values of a conversion: the
+// seed of the options when set, otherwise one derived from the content of the
+// spec, so that the same spec always yields the same output.
+func conversionSeed(opts Options, spec openapi3.T) int64 {
+	if opts.Seed != nil {
+		return *opts.Seed
+	}
+	data, err := json.Marshal(&spec)
+	if err != nil {
+		return time.Now().UnixNano()
+	}
+	hash := fnv.New64a()
+	hash.Write(data)
+	return int64(hash.Sum64())
+}
+
+// parseFileMode parses octal file permissions, e.g. 0640.
+func parseFileMode(value string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || mode == 0 || mode > 0777 {
+		return 0, fmt.Errorf("invalid mode %q, expected octal permissions like 0644", value)
+	}
+	return os.FileMode(mode), nil
+}
+
+// fileMode returns the permissions of the generated files.
+func (o Options) fileMode() os.FileMode {
+	if o.FileMode == 0 {
+		return 0644
+	}
+	return o.FileMode
+}
+
+// parsePort parses a port of the mock server, from 1 to 65535.
+func parsePort(value string) (int, error) {
+	port, err := strconv.Atoi(value)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q, expected 1 to 65535", value)
+	}
+	return port, nil
+}
+
+// parseDataDir validates the data folder, relative to the target folder.
+func parseDataDir(value string) (string, error) {
+	dataDir := filepath.ToSlash(filepath.Clean(value))
+	if value == "" || dataDir == "." || filepath.IsAbs(value) || dataDir == ".." || strings.HasPrefix(dataDir, "../") {
+		return "", fmt.Errorf("invalid data folder %q, expected a folder inside the target folder", value)
+	}
+	return dataDir, nil
+}
+
+// dataDir returns the folder of the target folder the mock servers are written
+// into, empty when they are written directly into the target folder.
+func (o Options) dataDir() string {
+	if o.NoDataDir {
+		return ""
+	}
+	if o.DataDir == "" {
+		return "data"
+	}
+	return o.DataDir
+}
+
+// dirMode returns the permissions of the generated folders.
+func (o Options) dirMode() os.FileMode {
+	if o.DirMode == 0 {
+		return 0755
+	}
+	return o.DirMode
+}
+
+// newRandom returns the random source of a conversion, seeded with the seed of
+// the conversion, see conversionSeed.
+func newRandom(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
+
+// rand returns the random source of the conversion, or a new one for options
+// used outside of a conversion.
+func (o Options) rand() *rand.Rand {
+	if o.random == nil {
+		if o.Seed != nil {
+			return newRandom(*o.Seed)
+		}
+		return newRandom(time.Now().UnixNano())
+	}
+	return o.random
+}
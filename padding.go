@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// padExtension is the extension of a response giving the size in bytes its body
+// is padded up to, to test the handling of large payloads.
+const padExtension = "x-mock-pad-bytes"
+
+// paddingField is the field added to the JSON object bodies to pad them.
+const paddingField = "_padding"
+
+// responsePadBytes returns the size the body of a response is padded up to,
+// from its padding extension. It is 0 without the extension.
+func responsePadBytes(response *openapi3.Response) (int, error) {
+	raw, ok := response.Extensions[padExtension]
+	if !ok {
+		return 0, nil
+	}
+	size, ok := raw.(float64)
+	if !ok || size < 0 || size != float64(int(size)) {
+		return 0, fmt.Errorf("invalid %s %v, expected a number of bytes", padExtension, raw)
+	}
+	return int(size), nil
+}
+
+// padBodies pads the bodies of the responses up to the size of their padding
+// extension, or to the size of the pad option without one.
+func padBodies(responses []Response, pad int) {
+	for i, response := range responses {
+		size := response.PadBytes
+		if size == 0 {
+			size = pad
+		}
+		if response.Body == nil || size == 0 {
+			continue
+		}
+		body := padBody(*response.Body, size)
+		responses[i].Body = &body
+	}
+}
+
+// padBody pads a body with filler up to a size in bytes. JSON objects get the
+// filler in an added _padding field, other bodies are padded with trailing
+// spaces, which keeps them valid. Bodies already as large are unchanged.
+func padBody(body string, size int) string {
+	if len(body) >= size {
+		return body
+	}
+	if strings.HasPrefix(strings.TrimSpace(body), "{") {
+		object := NewOrderedMap()
+		if err := json.Unmarshal([]byte(body), object); err == nil {
+			object.Set(paddingField, "")
+			if data, err := json.MarshalIndent(object, "", "  "); err == nil && len(data) <= size {
+				object.Set(paddingField, strings.Repeat("x", size-len(data)))
+				if data, err = json.MarshalIndent(object, "", "  "); err == nil {
+					return string(data)
+				}
+			}
+		}
+	}
+	return body + strings.Repeat(" ", size-len(body))
+}
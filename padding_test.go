@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const paddingSpec = `
+openapi: "3.0.0"
+info:
+  title: Padding API
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      responses:
+        "200":
+          description: OK
+          x-mock-pad-bytes: 1024
+          content:
+            application/json:
+              example:
+                name: Tom
+        "400":
+          description: Bad Request
+          content:
+            text/plain:
+              example: Invalid pet
+`
+
+func TestPadding(t *testing.T) {
+	setting := ConvertOpenAPIToMockServer(loadTestSpec(t, paddingSpec), Options{Pad: 64})
+
+	bodies := map[int]string{}
+	for _, response := range setting.Requests[0].Responses {
+		bodies[response.Code] = *response.Body
+	}
+	if len(bodies[200]) != 1024 {
+		t.Errorf("Expected the body padded to 1024 bytes by the extension, got %d", len(bodies[200]))
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal([]byte(bodies[200]), &object); err != nil {
+		t.Fatalf("Expected the padded body to be JSON: %v", err)
+	}
+	if object["name"] != "Tom" || object[paddingField] == nil {
+		t.Errorf("Expected the padding in a field next to the example, got %v", object)
+	}
+	if len(bodies[400]) != 64 || !strings.HasPrefix(bodies[400], "Invalid pet ") {
+		t.Errorf("Expected the text body padded to 64 bytes by the option, got %q", bodies[400])
+	}
+}
+
+func TestPadBodyLargerThanSize(t *testing.T) {
+	if body := padBody(`{"name": "Tom"}`, 4); body != `{"name": "Tom"}` {
+		t.Errorf("Expected a larger body unchanged, got %q", body)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// yamlLinePattern finds the line reported by a YAML syntax error.
+	yamlLinePattern = regexp.MustCompile(`line (\d+):`)
+	// jsonFieldPattern finds the field reported by a JSON decoding error, e.g. "field Info.title".
+	jsonFieldPattern = regexp.MustCompile(`field ([A-Za-z0-9_.]+)`)
+)
+
+// ParseError is an error raised while parsing an OpenAPI document, located at a
+// line and column of the document when it is known.
+type ParseError struct {
+	Line    int    // 1-based line, 0 if unknown
+	Column  int    // 1-based column, 0 if unknown
+	Snippet string // lines of the document around the error
+	Err     error
+}
+
+func (e *ParseError) Error() string {
+	if e.Line == 0 {
+		return e.Err.Error()
+	}
+	location := fmt.Sprintf("line %d", e.Line)
+	if e.Column > 0 {
+		location += fmt.Sprintf(", column %d", e.Column)
+	}
+	if e.Snippet == "" {
+		return fmt.Sprintf("%s: %v", location, e.Err)
+	}
+	return fmt.Sprintf("%s: %v\n%s", location, e.Err, e.Snippet)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// supportedVersions are the major.minor OpenAPI versions the loader supports.
+var supportedVersions = []string{"3.0", "3.1"}
+
+// checkOpenApiVersion reports documents declaring an OpenAPI or Swagger version
+// that is not supported. Documents that are not valid YAML are left to the
+// loader, which locates the syntax error.
+func checkOpenApiVersion(data []byte) error {
+	var header struct {
+		OpenAPI string `yaml:"openapi"`
+		Swagger string `yaml:"swagger"`
+	}
+	if err := yaml.Unmarshal(data, &header); err != nil {
+		return nil
+	}
+
+	supported := strings.Join(supportedVersions, ", ")
+	switch {
+	case header.OpenAPI != "":
+		parts := strings.SplitN(header.OpenAPI, ".", 3)
+		if len(parts) < 2 || !slices.Contains(supportedVersions, parts[0]+"."+parts[1]) {
+			return fmt.Errorf("unsupported OpenAPI version %s, supported versions: %s", header.OpenAPI, supported)
+		}
+	case header.Swagger != "":
+		return fmt.Errorf("unsupported Swagger version %s, supported OpenAPI versions: %s", header.Swagger, supported)
+	default:
+		return fmt.Errorf("missing openapi version field, supported versions: %s", supported)
+	}
+	return nil
+}
+
+// locateParseError wraps an error of the OpenAPI loader into a ParseError pointing
+// at the offending line of the document, when it can be found.
+func locateParseError(data []byte, err error) error {
+	parseErr := &ParseError{Err: err}
+
+	var root yaml.Node
+	if yamlErr := yaml.Unmarshal(data, &root); yamlErr != nil {
+		// Syntax error, the YAML parser knows the line
+		if match := yamlLinePattern.FindStringSubmatch(yamlErr.Error()); match != nil {
+			parseErr.Line, _ = strconv.Atoi(match[1])
+		}
+		parseErr.Err = yamlErr
+	} else if match := jsonFieldPattern.FindStringSubmatch(err.Error()); match != nil {
+		// The document is valid YAML but a field has an unexpected type
+		if node := findFieldNode(&root, strings.Split(match[1], ".")); node != nil {
+			parseErr.Line, parseErr.Column = node.Line, node.Column
+		}
+	}
+
+	parseErr.Snippet = sourceSnippet(data, parseErr.Line)
+	return parseErr
+}
+
+// findFieldNode finds the value node of the field reported by a JSON decoding
+// error. The field is given as Go type and field names, e.g. ["Info", "title"];
+// the field is looked up under a key matching the type name when possible.
+func findFieldNode(node *yaml.Node, field []string) *yaml.Node {
+	name := field[len(field)-1]
+	parent := ""
+	if len(field) > 1 {
+		parent = strings.ToLower(field[len(field)-2])
+	}
+
+	var fallback *yaml.Node
+	var walk func(node *yaml.Node, key string) *yaml.Node
+	walk = func(node *yaml.Node, key string) *yaml.Node {
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				keyNode, valueNode := node.Content[i], node.Content[i+1]
+				if keyNode.Value == name {
+					if parent == "" || strings.ToLower(key) == parent {
+						return valueNode
+					}
+					if fallback == nil {
+						fallback = valueNode
+					}
+				}
+				if found := walk(valueNode, keyNode.Value); found != nil {
+					return found
+				}
+			}
+			return nil
+		}
+		for _, child := range node.Content {
+			if found := walk(child, key); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+
+	if found := walk(node, ""); found != nil {
+		return found
+	}
+	return fallback
+}
+
+// sourceSnippet returns the lines of the document around the given line, with
+// the line itself marked.
+func sourceSnippet(data []byte, line int) string {
+	if line <= 0 {
+		return ""
+	}
+	lines := strings.Split(string(data), "\n")
+	if line > len(lines) {
+		return ""
+	}
+
+	var snippet strings.Builder
+	for i := max(line-2, 1); i <= min(line+1, len(lines)); i++ {
+		marker := " "
+		if i == line {
+			marker = ">"
+		}
+		fmt.Fprintf(&snippet, "%s %4d | %s\n", marker, i, strings.TrimRight(lines[i-1], "\r"))
+	}
+	return strings.TrimRight(snippet.String(), "\n")
+}
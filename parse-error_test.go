@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestLoadOpenApiDataSyntaxError(t *testing.T) {
+	_, err := loadOpenApiData([]byte(`openapi: "3.0.0"
+info:
+  title: Broken API
+   version: 1.0.0
+paths: {}
+`))
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expect a ParseError, got %v", err)
+	}
+	if parseErr.Line != 4 {
+		t.Errorf("expect error on line 4, got %d", parseErr.Line)
+	}
+	if !strings.Contains(err.Error(), "line 4") || !strings.Contains(err.Error(), ">    4 |    version: 1.0.0") {
+		t.Errorf("expect error to mention the location and snippet, got:\n%v", err)
+	}
+}
+
+func TestLoadOpenApiDataTypeError(t *testing.T) {
+	_, err := loadOpenApiData([]byte(`openapi: "3.0.0"
+info:
+  version: 1.0.0
+  title:
+    - not
+    - a string
+paths: {}
+`))
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("expect a ParseError, got %v", err)
+	}
+	if parseErr.Line != 5 || parseErr.Column != 5 {
+		t.Errorf("expect error at line 5, column 5, got line %d, column %d: %v", parseErr.Line, parseErr.Column, err)
+	}
+}
+
+func TestLoadOpenApiDataUnsupportedVersion(t *testing.T) {
+	for spec, expected := range map[string]string{
+		"openapi: 3.2.0\ninfo: {title: Future API, version: 1.0.0}\npaths: {}\n": "unsupported OpenAPI version 3.2.0, supported versions: 3.0, 3.1",
+		"swagger: \"2.0\"\ninfo: {title: Old API, version: 1.0.0}\npaths: {}\n":  "unsupported Swagger version 2.0",
+		"title: Not an API\n": "missing openapi version field",
+	} {
+		_, err := loadOpenApiData([]byte(spec))
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expect error %q, got %v", expected, err)
+		}
+	}
+
+	if _, err := loadOpenApiData([]byte("openapi: 3.1.0\ninfo: {title: API, version: 1.0.0}\npaths: {}\n")); err != nil {
+		t.Errorf("expect 3.1 to be supported, got %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"mime"
+)
+
+// placeholderEncoders encode the placeholder image of each image content type.
+var placeholderEncoders = map[string]func(w io.Writer, img image.Image) error{
+	"image/png":  png.Encode,
+	"image/jpeg": func(w io.Writer, img image.Image) error { return jpeg.Encode(w, img, nil) },
+	"image/gif":  func(w io.Writer, img image.Image) error { return gif.Encode(w, img, nil) },
+}
+
+// placeholderImage returns a valid 1x1 image of a content type: transparent,
+// or white for JPEG which has no transparency. It returns false for the other
+// content types.
+func placeholderImage(contentType string) (string, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", false
+	}
+	encode, ok := placeholderEncoders[mediaType]
+	if !ok {
+		return "", false
+	}
+	pixel := color.Color(color.Transparent)
+	if mediaType == "image/jpeg" {
+		pixel = color.White
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	img.Set(0, 0, pixel)
+
+	var buffer bytes.Buffer
+	if err := encode(&buffer, img); err != nil {
+		return "", false
+	}
+	return buffer.String(), true
+}
+
+// addPlaceholderImages gives the image responses without a body a placeholder
+// image, so that clients get a real image to render.
+func addPlaceholderImages(responses []Response) {
+	for i, response := range responses {
+		if response.Body != nil {
+			continue
+		}
+		if body, ok := placeholderImage(response.ContentType()); ok {
+			responses[i].Body = &body
+		}
+	}
+}
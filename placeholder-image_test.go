@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const imageSpec = `
+openapi: "3.0.0"
+info:
+  title: Image API
+  version: 1.0.0
+paths:
+  /avatar:
+    get:
+      responses:
+        "200":
+          description: OK
+          content:
+            image/png:
+              schema:
+                type: string
+                format: binary
+            image/jpeg: {}
+`
+
+func TestPlaceholderImages(t *testing.T) {
+	targetFolder := t.TempDir()
+	setting, _ := generateInto(t, targetFolder, imageSpec, Options{Port: 8080})
+
+	formats := map[string]string{}
+	for _, response := range setting.Requests[0].Responses {
+		if response.FilePath == nil {
+			t.Fatalf("Expected a placeholder body for %s", response.ContentType())
+		}
+		data, err := os.ReadFile(filepath.Join(targetFolder, *response.FilePath))
+		if err != nil {
+			t.Fatalf("Failed to read body: %v", err)
+		}
+		img, format, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("Expected a valid image for %s: %v", response.ContentType(), err)
+		}
+		if size := img.Bounds().Size(); size.X != 1 || size.Y != 1 {
+			t.Errorf("Expected a 1x1 image, got %v", size)
+		}
+		formats[response.ContentType()] = format
+	}
+	if formats["image/png"] != "png" || formats["image/jpeg"] != "jpeg" {
+		t.Errorf("Expected the placeholder chosen by content type, got %v", formats)
+	}
+	if _, ok := placeholderImage("application/json"); ok {
+		t.Errorf("Expected no placeholder for a JSON response")
+	}
+}
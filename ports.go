@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// siblingPorts returns the ports of the other mock servers written into the
+// same target folder, from their setting files.
+func (m *MockServerSetting) siblingPorts() (map[int]bool, error) {
+	ports := map[int]bool{}
+	dataFolder := filepath.Dir(m.Folder)
+	entries, err := os.ReadDir(dataFolder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read data folder: %w", err)
+	}
+	settingNames := []string{filepath.Base(m.settingFilePath()), "setting.yaml", "setting.json"}
+	for _, entry := range entries {
+		if !entry.IsDir() || filepath.Join(dataFolder, entry.Name()) == filepath.Clean(m.Folder) {
+			continue
+		}
+		for _, settingName := range settingNames {
+			data, err := os.ReadFile(filepath.Join(dataFolder, entry.Name(), settingName))
+			if err != nil {
+				continue
+			}
+
+			// JSON is valid YAML, so both setting formats are read the same way
+			var setting struct {
+				Port int `yaml:"port"`
+			}
+			if err := yaml.Unmarshal(data, &setting); err == nil && setting.Port != 0 {
+				ports[setting.Port] = true
+			}
+			break
+		}
+	}
+	return ports, nil
+}
+
+// assignBasePort gives the mock server the first port from the base port that
+// no other mock server of the target folder uses, so that the mock servers
+// generated into the same folder can run together.
+func (m *MockServerSetting) assignBasePort() error {
+	used, err := m.siblingPorts()
+	if err != nil {
+		return err
+	}
+	port := m.Options.BasePort
+	for used[port] {
+		port++
+	}
+	if port > 65535 {
+		return fmt.Errorf("no port left from the base port %d", m.Options.BasePort)
+	}
+	m.Port = port
+	infof("Mock server port is %d", port)
+	return nil
+}
+
+// portsLockName is the file name of the lockfile listing the host and port of
+// the mock servers of a target folder.
+const portsLockName = "ports.lock"
+
+// portsLock is the content of the ports lockfile, with an entry per mock
+// server of the target folder.
+type portsLock struct {
+	Mocks []portsLockEntry `json:"mocks"`
+}
+
+// portsLockEntry is the host and port chosen for a mock server.
+type portsLockEntry struct {
+	Name        string `json:"name"`
+	Folder      string `json:"folder"`
+	Host        string `json:"host"`
+	Port        int    `json:"port"`
+	GeneratedAt string `json:"generatedAt"`
+}
+
+// portsLockMutex serializes the updates of the ports lockfiles, so that the
+// generations running concurrently keep each other's entries.
+var portsLockMutex sync.Mutex
+
+// savePortsLock records the host and port of the mock server in the ports
+// lockfile of its target folder, so that a supervisor can discover them. The
+// entries of the other mock servers of the folder are kept.
+func (m *MockServerSetting) savePortsLock(generatedAt time.Time) error {
+	portsLockMutex.Lock()
+	defer portsLockMutex.Unlock()
+
+	lockPath := filepath.Join(m.TargetFolder, portsLockName)
+	var lock portsLock
+	if data, err := os.ReadFile(lockPath); err == nil {
+		if err := json.Unmarshal(data, &lock); err != nil {
+			warnf("Failed to parse %s, rewritten: %v", lockPath, err)
+			lock = portsLock{}
+		}
+	}
+
+	entry := portsLockEntry{
+		Name:        m.Name,
+		Folder:      filepath.Base(m.Folder),
+		Host:        m.Host,
+		Port:        m.Port,
+		GeneratedAt: generatedAt.UTC().Format(time.RFC3339),
+	}
+	mocks := []portsLockEntry{}
+	for _, mock := range lock.Mocks {
+		if mock.Folder != entry.Folder {
+			mocks = append(mocks, mock)
+		}
+	}
+	lock.Mocks = append(mocks, entry)
+	sort.Slice(lock.Mocks, func(i, j int) bool {
+		return lock.Mocks[i].Folder < lock.Mocks[j].Folder
+	})
+
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal ports lockfile: %w", err)
+	}
+	if err := os.WriteFile(lockPath, append(data, '\n'), m.Options.fileMode()); err != nil {
+		return fmt.Errorf("failed to write ports lockfile: %w", err)
+	}
+	infof("Mock server port is recorded in %s", lockPath)
+	return nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestBasePort(t *testing.T) {
+	targetFolder := t.TempDir()
+	opts := Options{BasePort: 9000}
+
+	ports := map[string]int{}
+	for _, title := range []string{"Pets API", "Owners API", "Toys API"} {
+		spec := strings.Replace(mergeSpec, "Merge API", title, 1)
+		_, saved := generateInto(t, targetFolder, spec, opts)
+		ports[title] = saved["port"].(int)
+	}
+	if ports["Pets API"] != 9000 || ports["Owners API"] != 9001 || ports["Toys API"] != 9002 {
+		t.Errorf("Expected distinct ports from the base port, got %v", ports)
+	}
+
+	// A regenerated mock server keeps its port
+	_, saved := generateInto(t, targetFolder, strings.Replace(mergeSpec, "Merge API", "Owners API", 1), opts)
+	if saved["port"] != 9001 {
+		t.Errorf("Expected the regenerated mock server to keep its port, got %v", saved["port"])
+	}
+}
+
+func TestPortsLock(t *testing.T) {
+	specFolder, targetFolder := t.TempDir(), t.TempDir()
+	for _, title := range []string{"Pets API", "Toys API"} {
+		openApiFile := filepath.Join(specFolder, cleanFolderName(title)+".yaml")
+		if err := os.WriteFile(openApiFile, []byte(strings.Replace(mergeSpec, "Merge API", title, 1)), 0644); err != nil {
+			t.Fatalf("Failed to write spec: %v", err)
+		}
+		if err := Generate(openApiFile, targetFolder, Options{Host: "127.0.0.1", BasePort: 9000, PortsLock: true}); err != nil {
+			t.Fatalf("Failed to generate %s: %v", title, err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(targetFolder, portsLockName))
+	if err != nil {
+		t.Fatalf("Failed to read lockfile: %v", err)
+	}
+	var lock portsLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+	if len(lock.Mocks) != 2 {
+		t.Fatalf("Expected an entry per mock server, got %+v", lock.Mocks)
+	}
+	for _, mock := range lock.Mocks {
+		data, err := os.ReadFile(filepath.Join(targetFolder, "data", mock.Folder, "setting.yaml"))
+		if err != nil {
+			t.Fatalf("Failed to read setting of %s: %v", mock.Folder, err)
+		}
+		var setting MockServerSetting
+		if err := yaml.Unmarshal(data, &setting); err != nil {
+			t.Fatalf("Failed to parse setting: %v", err)
+		}
+		if mock.Name != setting.Name || mock.Host != setting.Host || mock.Port != setting.Port {
+			t.Errorf("Expected the lockfile entry to match the setting %s %s:%d, got %+v", setting.Name, setting.Host, setting.Port, mock)
+		}
+		if _, err := time.Parse(time.RFC3339, mock.GeneratedAt); err != nil {
+			t.Errorf("Expected an RFC 3339 timestamp, got %q", mock.GeneratedAt)
+		}
+	}
+}
+
+func TestPortsLockConcurrent(t *testing.T) {
+	targetFolder := t.TempDir()
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			setting := MockServerSetting{Name: fmt.Sprintf("API %d", i), Host: "127.0.0.1", Port: 9000 + i}
+			setting.TargetFolder = targetFolder
+			setting.Folder = filepath.Join(targetFolder, "data", fmt.Sprintf("API_%d", i))
+			errs <- setting.savePortsLock(time.Now())
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("Failed to save lockfile: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(targetFolder, portsLockName))
+	if err != nil {
+		t.Fatalf("Failed to read lockfile: %v", err)
+	}
+	var lock portsLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		t.Fatalf("Failed to parse lockfile: %v", err)
+	}
+	if len(lock.Mocks) != 20 {
+		t.Errorf("Expected an entry per concurrent mock server, got %d", len(lock.Mocks))
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// requestExamplesFolderName is the name of the folder the request examples are
+// written into, under the folder of their request.
+const requestExamplesFolderName = "request"
+
+// RequestExample is a sample body of the request body of an operation, to
+// replay valid requests against the mock server.
+type RequestExample struct {
+	Name        string  `yaml:"name" json:"name"`
+	ContentType string  `yaml:"contentType" json:"contentType"`
+	FilePath    *string `yaml:"filePath,omitempty" json:"filePath,omitempty"`
+	Body        *string `yaml:"-" json:"-"` // Body is not saved in the setting file
+}
+
+// requestExamples returns the examples of the request body of an operation,
+// by content type and name. The singular example of a content type is named
+// "example".
+func requestExamples(operation *openapi3.Operation) []RequestExample {
+	if operation == nil || operation.RequestBody == nil || operation.RequestBody.Value == nil {
+		return nil
+	}
+	content := operation.RequestBody.Value.Content
+	contentTypes := make([]string, 0, len(content))
+	for contentType := range content {
+		contentTypes = append(contentTypes, contentType)
+	}
+	sort.Strings(contentTypes)
+
+	examples := []RequestExample{}
+	for _, contentType := range contentTypes {
+		mediaType := content[contentType]
+		if mediaType == nil {
+			continue
+		}
+		if body := exampleBodyString(mediaType.Example); body != "" {
+			examples = append(examples, RequestExample{Name: "example", ContentType: contentType, Body: &body})
+		}
+		names := make([]string, 0, len(mediaType.Examples))
+		for name := range mediaType.Examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if body := getBodyString(mediaType.Examples[name]); body != "" {
+				examples = append(examples, RequestExample{Name: name, ContentType: contentType, Body: &body})
+			}
+		}
+	}
+	return examples
+}
+
+// saveRequestExamples writes the request examples of each request into the
+// request folder under its folder.
+func (m *MockServerSetting) saveRequestExamples() error {
+	for i, request := range m.Requests {
+		for j, example := range request.Examples {
+			if example.Body == nil {
+				continue
+			}
+			folderRelativePath := fmt.Sprintf("%s/%s/%s", request.Method, requestFolderName(request), requestExamplesFolderName)
+			fileName := cleanFolderName(example.Name) + fileExtension(example.ContentType, m.Options.Extensions)
+			fileRelativePath, err := m.recordedPath(fmt.Sprintf("%s/%s", folderRelativePath, fileName))
+			if err != nil {
+				return err
+			}
+			m.Requests[i].Examples[j].FilePath = &fileRelativePath
+
+			folderFullPath := fmt.Sprintf("%s/%s", m.Folder, folderRelativePath)
+			if err := ensureFolder(folderFullPath, m.Options.dirMode()); err != nil {
+				return fmt.Errorf("failed to create request example folder: %w", err)
+			}
+			body := *example.Body
+			if m.Options.TrailingNewline {
+				body = strings.TrimRight(body, "\n") + "\n"
+			}
+			if err := os.WriteFile(fmt.Sprintf("%s/%s", folderFullPath, fileName), []byte(body), m.Options.fileMode()); err != nil {
+				return fmt.Errorf("failed to write request example to file: %w", err)
+			}
+			infof("Request example is saved to %s", fileRelativePath)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSaveRequestExamples(t *testing.T) {
+	setting := generateTestMock(t, `
+openapi: "3.0.0"
+info:
+  title: Order API
+  version: 1.0.0
+paths:
+  /orders:
+    post:
+      operationId: createOrder
+      requestBody:
+        content:
+          application/json:
+            examples:
+              small:
+                value: {"quantity": 1}
+      responses:
+        '201':
+          description: Created
+  /orders/{id}:
+    get:
+      operationId: getOrder
+      responses:
+        '200':
+          description: OK
+`, Options{EmitRequestExamples: true, PathStyle: PathStyleRelative})
+
+	for _, request := range setting.Requests {
+		switch request.Name {
+		case "createOrder":
+			if len(request.Examples) != 1 || request.Examples[0].FilePath == nil {
+				t.Fatalf("expect one saved request example, got %+v", request.Examples)
+			}
+			example := request.Examples[0]
+			if *example.FilePath != "./POST/createOrder/request/small.json" || example.ContentType != "application/json" {
+				t.Errorf("unexpected request example %s (%s)", *example.FilePath, example.ContentType)
+			}
+			data, err := os.ReadFile(filepath.Join(setting.Folder, *example.FilePath))
+			if err != nil {
+				t.Fatalf("Failed to read request example: %v", err)
+			}
+			if string(data) != "{\n  \"quantity\": 1\n}" {
+				t.Errorf("unexpected request example body %q", data)
+			}
+		case "getOrder":
+			if len(request.Examples) != 0 {
+				t.Errorf("expect no request example without a request body, got %+v", request.Examples)
+			}
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(setting.Folder, "setting.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read setting: %v", err)
+	}
+	if !strings.Contains(string(data), "requestExamples:") {
+		t.Errorf("expect the request examples in the setting, got %s", data)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// rewriteSeparator separates the pattern of a path rewrite from its
+// replacement, e.g. /old/{id}=>/new/{id}.
+const rewriteSeparator = "=>"
+
+// rewriteParamPattern matches the parameters of the path templates of a
+// rewrite, e.g. {id}.
+var rewriteParamPattern = regexp.MustCompile(`^\{([A-Za-z_][A-Za-z0-9_]*)\}$`)
+
+// PathRewrite rewrites the paths of the requests starting with its pattern. The
+// parameters of the pattern, e.g. {id}, match any segment, which is kept as is
+// where the replacement names the parameter, so that path parameters are
+// preserved.
+type PathRewrite struct {
+	Pattern     *regexp.Regexp
+	Replacement string
+}
+
+// parsePathRewrite parses a path rewrite of the form pattern=>replacement, e.g.
+// /old/{id}=>/new/{id}.
+func parsePathRewrite(value string) (PathRewrite, error) {
+	pattern, replacement, ok := strings.Cut(value, rewriteSeparator)
+	pattern, replacement = strings.TrimSpace(pattern), strings.TrimSpace(replacement)
+	if !ok || !strings.HasPrefix(pattern, "/") || !strings.HasPrefix(replacement, "/") {
+		return PathRewrite{}, fmt.Errorf("invalid rewrite %q, expected /old/{id}=>/new/{id}", value)
+	}
+
+	// The parameters of the pattern capture a segment each
+	params := map[string]bool{}
+	expression := "^"
+	for _, segment := range strings.Split(strings.TrimRight(pattern, "/"), "/")[1:] {
+		if match := rewriteParamPattern.FindStringSubmatch(segment); match != nil {
+			if params[match[1]] {
+				return PathRewrite{}, fmt.Errorf("invalid rewrite %q, parameter {%s} is repeated", value, match[1])
+			}
+			params[match[1]] = true
+			expression += "/(?P<" + match[1] + ">[^/]+)"
+		} else if strings.ContainsAny(segment, "{}") || segment == "" {
+			return PathRewrite{}, fmt.Errorf("invalid rewrite %q, invalid segment %q", value, segment)
+		} else {
+			expression += "/" + regexp.QuoteMeta(segment)
+		}
+	}
+	expression += "(?P<rest>/.*)?$"
+
+	// The replacement refers to the parameters of the pattern only
+	template := ""
+	for _, segment := range strings.Split(strings.TrimRight(replacement, "/"), "/")[1:] {
+		if match := rewriteParamPattern.FindStringSubmatch(segment); match != nil {
+			if !params[match[1]] {
+				return PathRewrite{}, fmt.Errorf("invalid rewrite %q, parameter {%s} is not in the pattern", value, match[1])
+			}
+			template += "/${" + match[1] + "}"
+		} else if strings.ContainsAny(segment, "{}$") || segment == "" {
+			return PathRewrite{}, fmt.Errorf("invalid rewrite %q, invalid segment %q", value, segment)
+		} else {
+			template += "/" + segment
+		}
+	}
+	if params["rest"] {
+		return PathRewrite{}, fmt.Errorf("invalid rewrite %q, parameter {rest} is reserved", value)
+	}
+	return PathRewrite{Pattern: regexp.MustCompile(expression), Replacement: template + "${rest}"}, nil
+}
+
+// rewritePath rewrites a path with the first rewrite whose pattern matches it.
+func rewritePath(path string, rewrites []PathRewrite) string {
+	for _, rewrite := range rewrites {
+		if match := rewrite.Pattern.FindStringSubmatchIndex(path); match != nil {
+			rewritten := string(rewrite.Pattern.ExpandString(nil, rewrite.Replacement, path, match))
+			if rewritten == "" {
+				rewritten = "/"
+			}
+			return rewritten
+		}
+	}
+	return path
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestRewritePaths(t *testing.T) {
+	spec := `
+openapi: "3.0.0"
+info:
+  title: Rewrite API
+  version: 1.0.0
+paths:
+  /old/{petId}:
+    summary: A pet
+    get:
+      responses:
+        "204":
+          description: No Content
+  /old/{petId}/toys:
+    get:
+      responses:
+        "204":
+          description: No Content
+  /other:
+    get:
+      responses:
+        "204":
+          description: No Content
+`
+	rewrite, err := parsePathRewrite("/old/{id}=>/v2/pets/{id}")
+	if err != nil {
+		t.Fatalf("Failed to parse rewrite: %v", err)
+	}
+	setting := ConvertOpenAPIToMockServer(loadTestSpec(t, spec), Options{Rewrites: []PathRewrite{rewrite}})
+
+	var paths []string
+	for _, request := range setting.Requests {
+		paths = append(paths, request.Path)
+	}
+	sort.Strings(paths)
+	if joined := strings.Join(paths, ","); joined != "/other,/v2/pets/{petId},/v2/pets/{petId}/toys" {
+		t.Errorf("Expected the paths rewritten with their parameters, got %s", joined)
+	}
+	if setting.Paths["/v2/pets/{petId}"].Summary != "A pet" {
+		t.Errorf("Expected the path infos rewritten, got %v", setting.Paths)
+	}
+}
+
+func TestParsePathRewrite(t *testing.T) {
+	for _, value := range []string{
+		"/old/{id}",
+		"old=>/new",
+		"/old/{id}=>/new/{other}",
+		"/old/{id}/{id}=>/new/{id}",
+		"/old/x{id}=>/new",
+		"/old//x=>/new",
+		"/old/{rest}=>/new/{rest}",
+	} {
+		if _, err := parsePathRewrite(value); err == nil {
+			t.Errorf("Expected %q to be rejected", value)
+		}
+	}
+
+	rewrite, err := parsePathRewrite("/a/{x}/b/{y}=>/{y}/c/{x}")
+	if err != nil {
+		t.Fatalf("Failed to parse rewrite: %v", err)
+	}
+	for path, expected := range map[string]string{
+		"/a/1/b/2":   "/2/c/1",
+		"/a/1/b/2/d": "/2/c/1/d",
+		"/a/1/bb/2":  "/a/1/bb/2",
+		"/a/1":       "/a/1",
+	} {
+		if rewritten := rewritePath(path, []PathRewrite{rewrite}); rewritten != expected {
+			t.Errorf("Expected %s rewritten to %s, got %s", path, expected, rewritten)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// schemaRefWalker walks the schemas of a spec, through the same path items,
+// operations, parameters, bodies and responses as getRequests, and reports the
+// references to the component schemas. A reference is reported by the name of
+// the component schema it resolves to.
+type schemaRefWalker struct {
+	names   map[*openapi3.Schema]string // component schema names, by schema
+	visited map[*openapi3.Schema]bool
+	// visit is called with the component schema a reference resolves to, and
+	// returns whether to walk into that schema.
+	visit func(name string) bool
+	// followRefs walks into the parameters, bodies, responses, headers and
+	// callbacks of the components the path items refer to.
+	followRefs bool
+}
+
+// newSchemaRefWalker returns a walker of the spec calling visit for each
+// reference to a component schema.
+func newSchemaRefWalker(openAPISpec openapi3.T, followRefs bool, visit func(name string) bool) *schemaRefWalker {
+	w := &schemaRefWalker{names: map[*openapi3.Schema]string{}, visited: map[*openapi3.Schema]bool{}, visit: visit, followRefs: followRefs}
+	if openAPISpec.Components != nil {
+		for name, schemaRef := range openAPISpec.Components.Schemas {
+			if schemaRef != nil && schemaRef.Ref == "" && schemaRef.Value != nil {
+				w.names[schemaRef.Value] = name
+			}
+		}
+	}
+	return w
+}
+
+// skipRef reports whether a reference to another component is not walked, as
+// the component is walked with the components.
+func (w *schemaRefWalker) skipRef(ref string) bool {
+	return !w.followRefs && strings.HasPrefix(ref, "#/components/")
+}
+
+// schemaName returns the name of the component schema a reference resolves to,
+// or an empty string for inline schemas and schemas of other files.
+func (w *schemaRefWalker) schemaName(schemaRef *openapi3.SchemaRef) string {
+	if schemaRef.Ref == "" {
+		return ""
+	}
+	if name, ok := strings.CutPrefix(schemaRef.Ref, "#/components/schemas/"); ok {
+		return name
+	}
+	// References to the spec from other files resolve to the same schemas
+	return w.names[schemaRef.Value]
+}
+
+// walkPathItems walks the operations of the path items of the spec and of its
+// webhooks.
+func (w *schemaRefWalker) walkPathItems(openAPISpec openapi3.T) {
+	if openAPISpec.Paths != nil {
+		for _, pathItem := range openAPISpec.Paths.Map() {
+			w.walkPathItem(pathItem)
+		}
+	}
+	for _, pathItem := range getWebhooks(openAPISpec) {
+		w.walkPathItem(pathItem)
+	}
+}
+
+// walkComponents walks the components of the spec that hold schemas.
+func (w *schemaRefWalker) walkComponents(components *openapi3.Components) {
+	if components == nil {
+		return
+	}
+	for _, schemaRef := range components.Schemas {
+		if schemaRef != nil && schemaRef.Ref != "" {
+			w.walkSchemaRef(schemaRef)
+		} else if schemaRef != nil {
+			w.walkSchema(schemaRef.Value)
+		}
+	}
+	for _, parameter := range components.Parameters {
+		w.walkParameter(parameter)
+	}
+	for _, requestBody := range components.RequestBodies {
+		w.walkRequestBody(requestBody)
+	}
+	for _, response := range components.Responses {
+		w.walkResponse(response)
+	}
+	for _, header := range components.Headers {
+		w.walkHeader(header)
+	}
+	for _, callback := range components.Callbacks {
+		w.walkCallback(callback)
+	}
+}
+
+func (w *schemaRefWalker) walkPathItem(pathItem *openapi3.PathItem) {
+	if pathItem == nil {
+		return
+	}
+	for _, parameter := range pathItem.Parameters {
+		w.walkParameter(parameter)
+	}
+	for _, operation := range pathItem.Operations() {
+		for _, parameter := range operation.Parameters {
+			w.walkParameter(parameter)
+		}
+		w.walkRequestBody(operation.RequestBody)
+		if operation.Responses != nil {
+			for _, response := range operation.Responses.Map() {
+				w.walkResponse(response)
+			}
+		}
+		for _, callback := range operation.Callbacks {
+			w.walkCallback(callback)
+		}
+	}
+}
+
+func (w *schemaRefWalker) walkCallback(callback *openapi3.CallbackRef) {
+	if callback == nil || callback.Value == nil || w.skipRef(callback.Ref) {
+		return
+	}
+	for _, pathItem := range callback.Value.Map() {
+		w.walkPathItem(pathItem)
+	}
+}
+
+func (w *schemaRefWalker) walkParameter(parameter *openapi3.ParameterRef) {
+	if parameter == nil || parameter.Value == nil || w.skipRef(parameter.Ref) {
+		return
+	}
+	w.walkSchemaRef(parameter.Value.Schema)
+	w.walkContent(parameter.Value.Content)
+}
+
+func (w *schemaRefWalker) walkRequestBody(requestBody *openapi3.RequestBodyRef) {
+	if requestBody == nil || requestBody.Value == nil || w.skipRef(requestBody.Ref) {
+		return
+	}
+	w.walkContent(requestBody.Value.Content)
+}
+
+func (w *schemaRefWalker) walkResponse(response *openapi3.ResponseRef) {
+	if response == nil || response.Value == nil || w.skipRef(response.Ref) {
+		return
+	}
+	for _, header := range response.Value.Headers {
+		w.walkHeader(header)
+	}
+	w.walkContent(response.Value.Content)
+}
+
+func (w *schemaRefWalker) walkHeader(header *openapi3.HeaderRef) {
+	if header == nil || header.Value == nil || w.skipRef(header.Ref) {
+		return
+	}
+	w.walkSchemaRef(header.Value.Schema)
+	w.walkContent(header.Value.Content)
+}
+
+func (w *schemaRefWalker) walkContent(content openapi3.Content) {
+	for _, mediaType := range content {
+		if mediaType != nil {
+			w.walkSchemaRef(mediaType.Schema)
+		}
+	}
+}
+
+// walkSchemaRef reports a reference to a component schema, and walks into the
+// schema unless visit declines. Inline schemas and schemas of other files are
+// always walked, as their references are found nowhere else.
+func (w *schemaRefWalker) walkSchemaRef(schemaRef *openapi3.SchemaRef) {
+	if schemaRef == nil {
+		return
+	}
+	if name := w.schemaName(schemaRef); name != "" && !w.visit(name) {
+		return
+	}
+	w.walkSchema(schemaRef.Value)
+}
+
+func (w *schemaRefWalker) walkSchema(schema *openapi3.Schema) {
+	if schema == nil || w.visited[schema] {
+		return
+	}
+	w.visited[schema] = true
+	for _, property := range schema.Properties {
+		w.walkSchemaRef(property)
+	}
+	w.walkSchemaRef(schema.Items)
+	w.walkSchemaRef(schema.AdditionalProperties.Schema)
+	w.walkSchemaRef(schema.Not)
+	for _, schemas := range []openapi3.SchemaRefs{schema.AllOf, schema.OneOf, schema.AnyOf} {
+		for _, schemaRef := range schemas {
+			w.walkSchemaRef(schemaRef)
+		}
+	}
+	// The loader keeps the tuples of OpenAPI 3.1 unparsed, see prefixItemSchemas
+	w.walkRawRefs(schema.Extensions["prefixItems"])
+}
+
+// walkRawRefs reports the references to component schemas of an unparsed part
+// of the spec.
+func (w *schemaRefWalker) walkRawRefs(value interface{}) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if ref, ok := value["$ref"].(string); ok {
+			if name, ok := strings.CutPrefix(ref, "#/components/schemas/"); ok {
+				w.visit(name)
+			}
+		}
+		for _, item := range value {
+			w.walkRawRefs(item)
+		}
+	case []interface{}:
+		for _, item := range value {
+			w.walkRawRefs(item)
+		}
+	}
+}
+
+// unusedSchemas returns the names of the component schemas that are not
+// referenced by any path or webhook, directly or through other component
+// schemas, in order.
+func unusedSchemas(openAPISpec openapi3.T) []string {
+	unused := []string{}
+	if openAPISpec.Components == nil {
+		return unused
+	}
+	used := map[string]bool{}
+	newSchemaRefWalker(openAPISpec, true, func(name string) bool {
+		if used[name] {
+			return false
+		}
+		used[name] = true
+		return true
+	}).walkPathItems(openAPISpec)
+	for name := range openAPISpec.Components.Schemas {
+		if !used[name] {
+			unused = append(unused, name)
+		}
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+// schemaRefCounts counts the references to each component schema in the paths,
+// webhooks and components of the spec. Unreferenced schemas count 0.
+func schemaRefCounts(openAPISpec openapi3.T) map[string]int {
+	counts := map[string]int{}
+	if openAPISpec.Components != nil {
+		for name := range openAPISpec.Components.Schemas {
+			counts[name] = 0
+		}
+	}
+	// The references of a component schema are counted once, with the
+	// components, not at each reference to it
+	walker := newSchemaRefWalker(openAPISpec, false, func(name string) bool {
+		counts[name]++
+		return false
+	})
+	walker.walkPathItems(openAPISpec)
+	walker.walkComponents(openAPISpec.Components)
+	return counts
+}
+
+// saveRefsReport writes the reference count of each component schema into
+// refs.json, sorted by schema name.
+func (m *MockServerSetting) saveRefsReport() error {
+	if m.Spec == nil {
+		return nil
+	}
+	counts := schemaRefCounts(*m.Spec)
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	report := NewOrderedMap()
+	for _, name := range names {
+		report.Set(name, counts[name])
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal schema references: %w", err)
+	}
+	refsFilePath := fmt.Sprintf("%s/refs.json", m.Folder)
+	if err := os.WriteFile(refsFilePath, data, m.Options.fileMode()); err != nil {
+		return fmt.Errorf("failed to write schema references to file: %w", err)
+	}
+	infof("Schema references are saved to %s", refsFilePath)
+	return nil
+}
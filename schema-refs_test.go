@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUnusedSchemas(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Pet API
+  version: 1.0.0
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      responses:
+        '200':
+          $ref: "#/components/responses/PetResponse"
+components:
+  responses:
+    PetResponse:
+      description: OK
+      content:
+        application/json:
+          schema:
+            $ref: "#/components/schemas/Pet"
+  schemas:
+    Pet:
+      type: object
+      properties:
+        owner:
+          $ref: "#/components/schemas/Owner"
+    Owner:
+      type: object
+    Orphan:
+      type: object
+`)
+	if unused := unusedSchemas(spec); strings.Join(unused, ",") != "Orphan" {
+		t.Errorf("expect only Orphan to be unused, got %v", unused)
+	}
+}
+
+func TestSaveRefsReport(t *testing.T) {
+	setting := generateTestMock(t, `
+openapi: "3.0.0"
+info:
+  title: Pet API
+  version: 1.0.0
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                type: array
+                items:
+                  $ref: "#/components/schemas/Pet"
+    post:
+      operationId: createPet
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: "#/components/schemas/Pet"
+      responses:
+        '201':
+          description: Created
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/Pet"
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        owner:
+          $ref: "#/components/schemas/Owner"
+    Owner:
+      type: object
+    Orphan:
+      type: object
+`, Options{Report: true})
+
+	data, err := os.ReadFile(filepath.Join(setting.Folder, "refs.json"))
+	if err != nil {
+		t.Fatalf("Failed to read refs.json: %v", err)
+	}
+	var counts map[string]int
+	if err := json.Unmarshal(data, &counts); err != nil {
+		t.Fatalf("Invalid refs.json: %v", err)
+	}
+	if counts["Pet"] != 3 || counts["Owner"] != 1 || counts["Orphan"] != 0 || len(counts) != 3 {
+		t.Errorf("unexpected reference counts:\n%s", data)
+	}
+}
+
+func TestUnusedSchemasExternalRefs(t *testing.T) {
+	folder := t.TempDir()
+	files := map[string]string{
+		"openapi.yaml": `
+openapi: "3.0.0"
+info:
+  title: Pet API
+  version: 1.0.0
+paths:
+  /pets/{id}:
+    get:
+      operationId: getPet
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: ./pet.yaml#/Pet
+components:
+  schemas:
+    Owner:
+      type: object
+      properties:
+        address:
+          $ref: "#/components/schemas/Address"
+    Address:
+      type: object
+    Orphan:
+      type: object
+`,
+		"pet.yaml": `
+Pet:
+  type: object
+  properties:
+    owner:
+      $ref: ./openapi.yaml#/components/schemas/Owner
+`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(folder, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	spec, err := parseOpenApiFile(filepath.Join(folder, "openapi.yaml"), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if unused := unusedSchemas(spec); strings.Join(unused, ",") != "Orphan" {
+		t.Errorf("expect only Orphan to be unused, got %v", unused)
+	}
+	if counts := schemaRefCounts(spec); counts["Owner"] != 1 || counts["Address"] != 1 || counts["Orphan"] != 0 {
+		t.Errorf("unexpected reference counts %v", counts)
+	}
+}
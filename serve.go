@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// maxSpecSize is the maximum size of the OpenAPI documents accepted by the
+// conversion service.
+const maxSpecSize = 10 << 20
+
+// Timeouts of the conversion service.
+const (
+	serveReadHeaderTimeout = 10 * time.Second
+	serveReadTimeout       = time.Minute
+	serveIdleTimeout       = 2 * time.Minute
+)
+
+// runServe runs the conversion as an HTTP service: POST /convert with an
+// OpenAPI document returns the generated setting, or a zip archive of the mock
+// server folder with ?format=zip.
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	listen := flags.String("listen", ":8080", "address the service listens on")
+	flags.Parse(args)
+
+	infof("Serving OpenAPI conversion on %s", *listen)
+	return newConvertServer(*listen).ListenAndServe()
+}
+
+// newConvertServer returns the server of the conversion service. Its timeouts
+// keep slow clients from holding connections open.
+func newConvertServer(listen string) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/convert", convertHandler)
+	return &http.Server{
+		Addr:              listen,
+		Handler:           mux,
+		ReadHeaderTimeout: serveReadHeaderTimeout,
+		ReadTimeout:       serveReadTimeout,
+		IdleTimeout:       serveIdleTimeout,
+	}
+}
+
+// serveOptions returns the options of the conversion service. The documents
+// come from untrusted clients, so the extensions reading other files, e.g. CSV
+// files and external references, are disabled.
+func serveOptions() Options {
+	return Options{sandbox: true}
+}
+
+// convertHandler converts the OpenAPI document of the request body into a mock
+// server written to a temporary folder, and returns its setting or a zip archive
+// of the folder.
+func convertHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "yaml" && format != "zip" {
+		http.Error(w, fmt.Sprintf("invalid format %q, expected yaml or zip", format), http.StatusBadRequest)
+		return
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxSpecSize))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read OpenAPI document: %v", err), http.StatusBadRequest)
+		return
+	}
+	targetFolder, err := os.MkdirTemp("", "openapi-to-mock-server-")
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to create target folder: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(targetFolder)
+
+	// Keep the document in the mock server folder, as the command line does
+	openApiFile := filepath.Join(targetFolder, "openapi.yaml")
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		openApiFile = filepath.Join(targetFolder, "openapi.json")
+	}
+	if err := os.WriteFile(openApiFile, data, 0644); err != nil {
+		http.Error(w, fmt.Sprintf("failed to write OpenAPI document: %v", err), http.StatusInternalServerError)
+		return
+	}
+	openAPISpec, opts, err := loadCheckedOpenApiFile(openApiFile, serveOptions())
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to parse OpenAPI document: %v", err), http.StatusBadRequest)
+		return
+	}
+	mockServerInfo := ConvertOpenAPIToMockServer(openAPISpec, opts)
+	err = mockServerInfo.CreateFolder(targetFolder)
+	if err == nil {
+		err = mockServerInfo.SaveSetting()
+	}
+	if err == nil {
+		err = mockServerInfo.CopyOpenAPIFile(openApiFile)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to generate mock server: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	if format == "zip" {
+		var archive bytes.Buffer
+		if err := zipFolder(mockServerInfo.Folder, &archive); err != nil {
+			http.Error(w, fmt.Sprintf("failed to archive mock server: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", cleanFolderName(mockServerInfo.Name)+".zip"))
+		w.Write(archive.Bytes())
+		return
+	}
+
+	setting, err := os.ReadFile(filepath.Join(mockServerInfo.Folder, "setting.yaml"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to read mock server setting: %v", err), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/yaml")
+	if _, err := w.Write(setting); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
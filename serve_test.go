@@ -0,0 +1,96 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestConvertHandler(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	convertHandler(recorder, httptest.NewRequest(http.MethodPost, "/convert", strings.NewReader(petSpec)))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expect status 200, got %d: %s", recorder.Code, recorder.Body)
+	}
+	if recorder.Header().Get("Content-Type") != "application/yaml" {
+		t.Errorf("expect YAML content type, got %s", recorder.Header().Get("Content-Type"))
+	}
+	setting := recorder.Body.String()
+	if !strings.Contains(setting, "name: Pet API") || !strings.Contains(setting, "path: /pets/{id}") {
+		t.Errorf("expect the generated setting, got:\n%s", setting)
+	}
+}
+
+func TestConvertHandlerZip(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	convertHandler(recorder, httptest.NewRequest(http.MethodPost, "/convert?format=zip", strings.NewReader(petSpec)))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expect status 200, got %d: %s", recorder.Code, recorder.Body)
+	}
+	archive, err := zip.NewReader(bytes.NewReader(recorder.Body.Bytes()), int64(recorder.Body.Len()))
+	if err != nil {
+		t.Fatalf("Invalid zip archive: %v", err)
+	}
+	files := map[string]bool{}
+	for _, file := range archive.File {
+		files[file.Name] = true
+	}
+	for _, name := range []string{"setting.yaml", "openapi.yaml", "GET/getPet/200/OK.json"} {
+		if !files[name] {
+			t.Errorf("expect %s in the archive, got %v", name, files)
+		}
+	}
+}
+
+func TestConvertHandlerErrors(t *testing.T) {
+	for _, test := range []struct {
+		method string
+		target string
+		body   string
+		code   int
+	}{
+		{http.MethodGet, "/convert", "", http.StatusMethodNotAllowed},
+		{http.MethodPost, "/convert", "openapi: 3.0.0\ninfo: [", http.StatusBadRequest},
+		{http.MethodPost, "/convert?format=tar", petSpec, http.StatusBadRequest},
+	} {
+		recorder := httptest.NewRecorder()
+		convertHandler(recorder, httptest.NewRequest(test.method, test.target, strings.NewReader(test.body)))
+		if recorder.Code != test.code {
+			t.Errorf("expect status %d for %s %s, got %d", test.code, test.method, test.target, recorder.Code)
+		}
+	}
+}
+
+func TestNewConvertServer(t *testing.T) {
+	server := newConvertServer("127.0.0.1:0")
+	if server.ReadHeaderTimeout == 0 || server.ReadTimeout == 0 || server.IdleTimeout == 0 {
+		t.Errorf("expect the server to time out slow clients, got %+v", server)
+	}
+
+	// The server routes the conversions
+	recorder := httptest.NewRecorder()
+	server.Handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/convert", strings.NewReader(petSpec)))
+	if recorder.Code != http.StatusOK {
+		t.Errorf("expect status 200, got %d: %s", recorder.Code, recorder.Body)
+	}
+}
+
+func TestConvertHandlerSandbox(t *testing.T) {
+	// The CSV files are not read from the server
+	spec := strings.Replace(csvSpec, "./data/users.csv", "/etc/hostname", 1)
+	recorder := httptest.NewRecorder()
+	convertHandler(recorder, httptest.NewRequest(http.MethodPost, "/convert", strings.NewReader(spec)))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("expect status 200, got %d: %s", recorder.Code, recorder.Body)
+	}
+	if setting := recorder.Body.String(); strings.Contains(setting, csvContentType) {
+		t.Errorf("expect the CSV file to be ignored, got:\n%s", setting)
+	}
+
+	if opts := serveOptions(); !opts.sandbox || opts.ExternalRefs {
+		t.Errorf("expect sandboxed options, got %+v", opts)
+	}
+}
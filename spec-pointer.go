@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// splitSpecPointer splits the JSON Pointer to the part of the spec to generate
+// from an OpenAPI file, e.g. spec.yaml#/paths/~1users. A file whose name holds
+// the fragment is kept as is.
+func splitSpecPointer(openApiFile string) (string, string) {
+	file, pointer, ok := strings.Cut(openApiFile, "#/")
+	if !ok {
+		return openApiFile, ""
+	}
+	if _, err := os.Stat(openApiFile); err == nil {
+		return openApiFile, ""
+	}
+	return file, "/" + pointer
+}
+
+// selectSpecPointer keeps only the part of the spec a JSON Pointer refers to:
+// all the paths (/paths), a path (/paths/~1users) or an operation of a path
+// (/paths/~1users/get). The references of the part are already resolved from
+// the whole document.
+func selectSpecPointer(openAPISpec *openapi3.T, pointer string) error {
+	tokens := strings.Split(strings.TrimPrefix(pointer, "/"), "/")
+	for i, token := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	if tokens[0] != "paths" || len(tokens) > 3 {
+		return fmt.Errorf("unsupported pointer %s, expected /paths, /paths/<path> or /paths/<path>/<method>", pointer)
+	}
+	if len(tokens) == 1 {
+		delete(openAPISpec.Extensions, "webhooks")
+		return nil
+	}
+
+	path := tokens[1]
+	pathItem := openAPISpec.Paths.Value(path)
+	if pathItem == nil {
+		return fmt.Errorf("pointer %s does not resolve: path %s not found", pointer, path)
+	}
+	if len(tokens) == 3 {
+		method := strings.ToUpper(tokens[2])
+		operation := pathItem.GetOperation(method)
+		if operation == nil {
+			return fmt.Errorf("pointer %s does not resolve: operation %s not found", pointer, tokens[2])
+		}
+		item := *pathItem
+		item.Connect, item.Delete, item.Get, item.Head, item.Options, item.Patch, item.Post, item.Put, item.Trace = nil, nil, nil, nil, nil, nil, nil, nil, nil
+		item.SetOperation(method, operation)
+		pathItem = &item
+	}
+	openAPISpec.Paths = openapi3.NewPaths(openapi3.WithPath(path, pathItem))
+	delete(openAPISpec.Extensions, "webhooks")
+	return nil
+}
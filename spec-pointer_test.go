@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const specPointerSpec = `
+openapi: "3.0.0"
+info:
+  title: Pointer API
+  version: 1.0.0
+paths:
+  /users:
+    get:
+      responses:
+        "200":
+          description: OK
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/User"
+    post:
+      responses:
+        "201":
+          description: Created
+  /orders:
+    get:
+      responses:
+        "204":
+          description: No Content
+components:
+  schemas:
+    User:
+      type: object
+      properties:
+        name:
+          type: string
+          example: Tom
+`
+
+func TestSpecPointer(t *testing.T) {
+	openApiFile := filepath.Join(t.TempDir(), "spec.yaml")
+	if err := os.WriteFile(openApiFile, []byte(specPointerSpec), 0644); err != nil {
+		t.Fatalf("Failed to write spec: %v", err)
+	}
+
+	cases := map[string]string{
+		"#/paths/~1users":     "GET /users,POST /users",
+		"#/paths/~1users/get": "GET /users",
+		"#/paths":             "GET /orders,GET /users,POST /users",
+	}
+	for fragment, expected := range cases {
+		target := t.TempDir()
+		if err := Generate(openApiFile+fragment, target, Options{Port: 8080, NoCopySpec: true}); err != nil {
+			t.Fatalf("Failed to generate %s: %v", fragment, err)
+		}
+		data, err := os.ReadFile(filepath.Join(target, "data", "Pointer_API", "setting.yaml"))
+		if err != nil {
+			t.Fatalf("Failed to read setting: %v", err)
+		}
+		var setting MockServerSetting
+		if err := yaml.Unmarshal(data, &setting); err != nil {
+			t.Fatalf("Failed to parse setting: %v", err)
+		}
+		var routes []string
+		for _, request := range setting.Requests {
+			routes = append(routes, request.Method+" "+request.Path)
+		}
+		sort.Strings(routes)
+		if strings.Join(routes, ",") != expected {
+			t.Errorf("Expected the routes of %s to be %s, got %v", fragment, expected, routes)
+		}
+		for _, request := range setting.Requests {
+			if request.Method != "GET" || request.Path != "/users" {
+				continue
+			}
+			body, err := os.ReadFile(filepath.Join(target, *request.Responses[0].FilePath))
+			if err != nil {
+				t.Fatalf("Failed to read body: %v", err)
+			}
+			if !strings.Contains(string(body), "Tom") {
+				t.Errorf("Expected the reference resolved from the whole spec, got %s", body)
+			}
+		}
+	}
+
+	for _, fragment := range []string{"#/paths/~1pets", "#/paths/~1users/delete", "#/components/schemas/User"} {
+		if err := Generate(openApiFile+fragment, t.TempDir(), Options{Port: 8080}); exitCode(err) != ExitValidation {
+			t.Errorf("Expected a validation error for %s, got %v", fragment, err)
+		}
+	}
+}
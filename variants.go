@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// variantsExtension is the extension of a response listing alternate bodies
+// for its code, e.g. for A/B mock responses:
+//
+//	x-mock-variants:
+//	  - name: premium
+//	    value: {"plan": "premium"}
+const variantsExtension = "x-mock-variants"
+
+// variantResponses returns a response for each variant of the variants
+// extension of a response, selected by the name of the variant. The variants
+// have the first content type of the response, JSON by default.
+func variantResponses(key string, code int, response *openapi3.Response, opts Options) []Response {
+	raw, ok := response.Extensions[variantsExtension]
+	if !ok {
+		return nil
+	}
+	variants, ok := raw.([]interface{})
+	if !ok {
+		warnf("invalid %s of response %s, expected a list of variants", variantsExtension, key)
+		return nil
+	}
+
+	contentType := "application/json"
+	if len(response.Content) > 0 {
+		contentTypes := make([]string, 0, len(response.Content))
+		for candidate := range response.Content {
+			contentTypes = append(contentTypes, candidate)
+		}
+		sort.Strings(contentTypes)
+		contentType = contentTypes[0]
+	}
+
+	responses := []Response{}
+	for i, rawVariant := range variants {
+		variant, _ := rawVariant.(map[string]interface{})
+		name, _ := variant["name"].(string)
+		if name == "" {
+			warnf("invalid %s of response %s, variant %d has no name", variantsExtension, key, i)
+			continue
+		}
+		variantResponse := Response{
+			Name:        cleanFolderName(name),
+			Description: fmt.Sprintf("%s (%s)", responseDescription(response), name),
+			Code:        code,
+			Query:       opts.selectorQuery(SelectorKey, key, SelectorContentType, contentType, SelectorName, name),
+			Headers:     &[]Header{{Name: "Content-Type", Value: contentType}},
+		}
+		if body := exampleBodyString(variant["value"]); body != "" {
+			variantResponse.Body = &body
+		}
+		responses = append(responses, variantResponse)
+	}
+	return responses
+}
+
+// responseDescription returns the description of a response of the spec.
+func responseDescription(response *openapi3.Response) string {
+	if response.Description == nil {
+		return ""
+	}
+	return *response.Description
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestExtractResponseVariants(t *testing.T) {
+	spec := loadTestSpec(t, `
+openapi: "3.0.0"
+info:
+  title: Plan API
+  version: 1.0.0
+paths:
+  /plan:
+    get:
+      operationId: getPlan
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              example: {"plan": "free"}
+          x-mock-variants:
+            - name: premium
+              value: {"plan": "premium"}
+            - name: trial
+              value: {"plan": "trial"}
+`)
+	requests := getRequests(spec, getSchemaExamples(spec, Options{}), Options{})
+
+	responses := []string{}
+	for _, response := range requests[0].Responses {
+		responses = append(responses, fmt.Sprintf("%d %s %s", response.Code, response.Name, response.Query))
+	}
+	expected := "[200 OK ?key=200&contentType=application/json" +
+		" 200 premium ?key=200&contentType=application/json&name=premium" +
+		" 200 trial ?key=200&contentType=application/json&name=trial]"
+	if fmt.Sprint(responses) != expected {
+		t.Errorf("expect a response per variant, got %v", responses)
+	}
+
+	sources := map[string]string{}
+	for _, response := range requests[0].Responses {
+		sources[response.Name] = *response.Body
+	}
+	if sources["trial"] != "{\n  \"plan\": \"trial\"\n}" {
+		t.Errorf("unexpected variant body %q", sources["trial"])
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// anchorRepeatedHeaders replaces the header lists repeated in a YAML document by
+// aliases of their first occurrence, which is given an anchor.
+func anchorRepeatedHeaders(root *yaml.Node) {
+	// Find the header lists, grouped by content
+	groups := map[string][]*yaml.Node{}
+	order := []string{}
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key, value := node.Content[i], node.Content[i+1]
+				if key.Value == "headers" && value.Kind == yaml.SequenceNode && len(value.Content) > 0 {
+					content := nodeContent(value)
+					if _, ok := groups[content]; !ok {
+						order = append(order, content)
+					}
+					groups[content] = append(groups[content], value)
+					continue
+				}
+				walk(value)
+			}
+			return
+		}
+		for _, child := range node.Content {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	// Anchor the first list of each repeated group and alias the others
+	count := 0
+	for _, content := range order {
+		nodes := groups[content]
+		if len(nodes) < 2 {
+			continue
+		}
+		count++
+		anchor := nodes[0]
+		anchor.Anchor = fmt.Sprintf("headers_%d", count)
+		for _, node := range nodes[1:] {
+			*node = yaml.Node{Kind: yaml.AliasNode, Value: anchor.Anchor, Alias: anchor}
+		}
+	}
+}
+
+// nodeContent returns a textual representation of the content of a node, equal
+// for nodes with the same content.
+func nodeContent(node *yaml.Node) string {
+	var content strings.Builder
+	var write func(node *yaml.Node)
+	write = func(node *yaml.Node) {
+		fmt.Fprintf(&content, "%d:%q(", node.Kind, node.Value)
+		for _, child := range node.Content {
+			write(child)
+		}
+		content.WriteString(")")
+	}
+	write(node)
+	return content.String()
+}
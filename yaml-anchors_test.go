@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestSaveSettingYAMLAnchors(t *testing.T) {
+	setting := generateTestMock(t, `
+openapi: "3.0.0"
+info:
+  title: User API
+  version: 1.0.0
+paths:
+  /users:
+    get:
+      operationId: listUsers
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              example: []
+  /groups:
+    get:
+      operationId: listGroups
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              example: []
+  /readme:
+    get:
+      operationId: getReadme
+      responses:
+        '200':
+          description: OK
+          content:
+            text/plain:
+              example: Hello
+`, Options{YAMLAnchors: true})
+
+	data, err := os.ReadFile(filepath.Join(setting.Folder, "setting.yaml"))
+	if err != nil {
+		t.Fatalf("Failed to read setting.yaml: %v", err)
+	}
+	content := string(data)
+	if strings.Count(content, "&headers_1") != 1 || strings.Count(content, "*headers_1") != 1 {
+		t.Errorf("expect the JSON header list to be anchored once and aliased once, got:\n%s", content)
+	}
+	if strings.Contains(content, "headers_2") || strings.Count(content, "value: application/json") != 1 {
+		t.Errorf("expect only repeated header lists to be anchored, got:\n%s", content)
+	}
+
+	// The aliases resolve to the same headers
+	var saved MockServerSetting
+	if err := yaml.Unmarshal(data, &saved); err != nil {
+		t.Fatalf("Invalid setting.yaml: %v", err)
+	}
+	for _, request := range saved.Requests {
+		if request.Name != "getReadme" && request.Responses[0].ContentType() != "application/json" {
+			t.Errorf("expect the aliased headers of %s to resolve, got %+v", request.Name, request.Responses[0].Headers)
+		}
+	}
+}
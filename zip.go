@@ -0,0 +1,55 @@
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// zipFolder writes the files of a folder into a zip archive, with paths relative
+// to the folder.
+func zipFolder(folder string, w io.Writer) error {
+	archive := zip.NewWriter(w)
+	err := filepath.WalkDir(folder, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		relativePath, err := filepath.Rel(folder, path)
+		if err != nil {
+			return err
+		}
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		entryWriter, err := archive.Create(filepath.ToSlash(relativePath))
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(entryWriter, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// SaveZip packages the mock server folder into a zip archive.
+func (m *MockServerSetting) SaveZip(zipFile string) error {
+	file, err := os.OpenFile(zipFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, m.Options.fileMode())
+	if err != nil {
+		return fmt.Errorf("failed to create zip archive: %w", err)
+	}
+	defer file.Close()
+	if err := zipFolder(m.Folder, file); err != nil {
+		return fmt.Errorf("failed to write zip archive: %w", err)
+	}
+	infof("Mock server folder is archived to %s", zipFile)
+	return nil
+}